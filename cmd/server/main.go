@@ -4,22 +4,75 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
-	"mcp-server/internal/mcp/core"
+	core "mcp-server/handler/mcp"
+	repotools "mcp-server/handler/mcp/tools"
+	"mcp-server/internal/chunk"
 	"mcp-server/internal/mcp/tools"
-	"mcp-server/internal/web"
 
 	"github.com/gin-gonic/gin"
 )
 
+// fetchSlots fetch_multi 的全局并发上限
+var fetchSlots = make(chan struct{}, 8)
+
 func main() {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	// 抓取器按主机附加的请求头、Cookie 与认证
+	if cfg, err := tools.AuthConfigFromEnv(); err != nil {
+		log.Printf("scraper config ignored: %v", err)
+	} else {
+		tools.SetDefaultAuth(cfg)
+	}
+
+	// 克隆私有仓库使用的凭据
+	if creds, err := repotools.CredentialsFromEnv(); err != nil {
+		log.Printf("git credentials ignored: %v", err)
+	} else {
+		repotools.SetDefaultCredentials(creds)
+	}
+
+	// 常驻进程使用磁盘响应缓存
+	cacheDir := os.Getenv("CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "mcp-server", "http")
+	}
+	if cache, err := tools.NewDiskCache(cacheDir, 512<<20); err != nil {
+		log.Printf("disk cache disabled: %v", err)
+	} else {
+		tools.SetDefaultCache(cache)
+	}
+
+	// 仓库克隆缓存为磁盘上的裸仓库，之后的请求增量拉取
+	repoDir := os.Getenv("REPO_CACHE_DIR")
+	if repoDir == "" {
+		repoDir = filepath.Join(os.TempDir(), "mcp-server", "repos")
+	}
+	if cache, err := repotools.NewDiskRepoCache(repoDir, 2<<30, 5*time.Minute); err != nil {
+		log.Printf("repo cache falls back to memory: %v", err)
+	} else {
+		repotools.SetDefaultRepoCache(cache)
+	}
+
+	// 页面监控快照保存在文件中
+	watchFile := os.Getenv("WATCH_FILE")
+	if watchFile == "" {
+		watchFile = filepath.Join(os.TempDir(), "mcp-server", "watches.json")
+	}
+	if store, err := tools.NewFileWatchStore(watchFile); err != nil {
+		log.Printf("watch store falls back to memory: %v", err)
+	} else {
+		tools.SetDefaultWatchStore(store)
+	}
+
 	// 创建 Gin 引擎
 	engine := gin.New()
 	engine.Use(gin.Recovery())
@@ -30,30 +83,13 @@ func main() {
 	// 注册工具
 	registerTools(mcpServer)
 
-	// 创建 Web 服务器
-	authCode := os.Getenv("AUTH_CODE")
-	webServer := web.New(authCode)
-
 	// 注册 MCP 端点
 	engine.POST("/mcp", mcpServer.Handler())
 
-	// 注册 Web 路由 - 使用 web 引擎作为处理器
-	webEngine := webServer.GetEngine()
-	webServer.SetupRoutes(mcpServer)
-
-	// 使用 webEngine 作为所有非 MCP 路由的处理器
-	engine.NoRoute(gin.WrapH(webEngine))
-
 	// 启动服务器
 	addr := fmt.Sprintf(":%s", port)
 	log.Printf("服务器启动在 http://localhost%s", addr)
 	log.Printf("MCP 端点: POST /mcp")
-	log.Printf("Web 界面: http://localhost%s/", addr)
-	log.Printf("工具管理: http://localhost%s/tools", addr)
-
-	if authCode != "" {
-		log.Printf("认证已启用，请使用 AUTH_CODE 环境变量设置的授权码")
-	}
 
 	if err := engine.Run(addr); err != nil {
 		log.Fatal("服务器启动失败:", err)
@@ -67,16 +103,37 @@ func registerTools(server *core.Server) {
 		core.NewTool("fetch").
 			Desc("抓取网页内容并转换为 Markdown 格式").
 			String("url", "要抓取的网页 URL", true).
+			Bool("respect_robots", "遵守 robots.txt 的 Disallow 与 Crawl-delay（可选）", false).
+			With(fetchParams).
+			With(chunkParams).
 			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts, err := fetchOptions(ctx)
+				if err != nil {
+					return ctx.Error("参数错误: " + err.Error())
+				}
 				url := ctx.String("url")
-				result, err := tools.QuickFetch(url)
+				result, err := tools.NewScraper().
+					WithOptions(opts).
+					RespectRobots(ctx.Bool("respect_robots")).
+					FetchToMarkdown(url)
 				if err != nil {
 					return ctx.Error("抓取失败: " + err.Error())
 				}
+				page, err := chunk.Page(result.Markdown, chunkOptions(ctx), ctx.Int("page"))
+				if err != nil {
+					return ctx.Error("分页失败: " + err.Error())
+				}
 				return ctx.JSON(core.H{
 					"url":      result.URL,
+					"type":     result.Type,
 					"title":    result.Title,
-					"markdown": result.Markdown,
+					"markdown": page.Text,
+					"page":     page.Page,
+					"pages":    page.Pages,
+					"next":     page.Next,
+					"metadata": result.Metadata,
+					"cache":    result.Cache,
+					"retries":  result.Retries,
 				})
 			}),
 	)
@@ -86,13 +143,70 @@ func registerTools(server *core.Server) {
 		core.NewTool("fetch_md").
 			Desc("抓取网页内容，仅返回 Markdown 文本").
 			String("url", "要抓取的网页 URL", true).
+			Bool("respect_robots", "遵守 robots.txt 的 Disallow 与 Crawl-delay（可选）", false).
+			With(fetchParams).
+			With(chunkParams).
 			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts, err := fetchOptions(ctx)
+				if err != nil {
+					return ctx.Error("参数错误: " + err.Error())
+				}
 				url := ctx.String("url")
-				result, err := tools.QuickFetch(url)
+				result, err := tools.NewScraper().
+					WithOptions(opts).
+					RespectRobots(ctx.Bool("respect_robots")).
+					FetchToMarkdown(url)
+				if err != nil {
+					return ctx.Error("抓取失败: " + err.Error())
+				}
+				page, err := chunk.Page(result.Markdown, chunkOptions(ctx), ctx.Int("page"))
+				if err != nil {
+					return ctx.Error("分页失败: " + err.Error())
+				}
+				return ctx.Markdown(page.Markdown())
+			}),
+	)
+
+	// 网页元数据工具 - 仅解析 <head>
+	server.Register(
+		core.NewTool("fetch_meta").
+			Desc("抓取网页元数据（描述、canonical、OpenGraph、Twitter Card、JSON-LD 等）").
+			String("url", "要抓取的网页 URL", true).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				meta, err := tools.FetchMetadata(ctx.String("url"))
 				if err != nil {
 					return ctx.Error("抓取失败: " + err.Error())
 				}
-				return ctx.Markdown(result.Markdown)
+				return ctx.JSON(meta)
+			}),
+	)
+
+	// 选择器抽取工具 - CSS / XPath
+	server.Register(
+		core.NewTool("scrape_select").
+			Desc("抓取网页并按 CSS 选择器或 XPath 抽取内容，返回每个选择器的匹配数组").
+			String("url", "要抓取的网页 URL", true).
+			Strings("css", "CSS 选择器列表，如 .release-note", false).
+			Strings("xpath", "XPath 表达式列表，如 //h2/a/@href", false).
+			String("attr", "要提取的属性名，为空时提取文本（可选）", false).
+			Bool("html", "提取内部 HTML 而非文本（可选）", false).
+			With(fetchParams).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts, err := fetchOptions(ctx)
+				if err != nil {
+					return ctx.Error("参数错误: " + err.Error())
+				}
+				scraper := tools.NewScraper().WithOptions(opts)
+				result, err := scraper.Select(ctx.String("url"), &tools.SelectOptions{
+					CSS:   ctx.Strings("css"),
+					XPath: ctx.Strings("xpath"),
+					Attr:  ctx.String("attr"),
+					HTML:  ctx.Bool("html"),
+				})
+				if err != nil {
+					return ctx.Error("抽取失败: " + err.Error())
+				}
+				return ctx.JSON(result)
 			}),
 	)
 
@@ -116,10 +230,11 @@ func registerTools(server *core.Server) {
 				}
 
 				type item struct {
-					URL      string `json:"url"`
-					Title    string `json:"title"`
-					Markdown string `json:"markdown"`
-					Error    string `json:"error,omitempty"`
+					URL      string   `json:"url"`
+					Title    string   `json:"title"`
+					Markdown string   `json:"markdown"`
+					Retries  []string `json:"retries,omitempty"`
+					Error    string   `json:"error,omitempty"`
 				}
 
 				results := make([]item, len(urls))
@@ -130,12 +245,16 @@ func registerTools(server *core.Server) {
 					i, u := i, u
 					go func() {
 						defer wg.Done()
+						// 所有 fetch_multi 调用共享并发上限
+						fetchSlots <- struct{}{}
+						defer func() { <-fetchSlots }()
+
 						res, err := tools.QuickFetch(u)
 						if err != nil {
 							results[i] = item{URL: u, Error: err.Error()}
 							return
 						}
-						results[i] = item{URL: u, Title: res.Title, Markdown: res.Markdown}
+						results[i] = item{URL: u, Title: res.Title, Markdown: res.Markdown, Retries: res.Retries}
 					}()
 				}
 
@@ -144,35 +263,184 @@ func registerTools(server *core.Server) {
 			}),
 	)
 
-	// GitHub 仓库文档下载工具
+	// 多页面爬取工具 - 限定深度、范围和页面数
 	server.Register(
-		core.NewTool("download_docs").
-			Desc("从 GitHub 仓库下载文档文件（.md, .txt），返回文件内容").
-			String("repo", "GitHub 仓库 URL，如 https://github.com/user/repo", true).
-			String("path", "文档路径过滤，如 docs（可选）", false).
+		core.NewTool("crawl").
+			Desc("从起始 URL 开始按深度跟随链接爬取站点，返回站点地图和每个页面的 Markdown").
+			String("url", "起始 URL", true).
+			Number("depth", "最大链接跳数，默认 2，最大 5（可选）", false).
+			Number("max_pages", "最大页面数，默认 20，最大 100（可选）", false).
+			String("scope", "爬取范围：domain（同域名，默认）或 prefix（同路径前缀）", false).
+			String("prefix", "prefix 范围的路径前缀，默认取起始 URL 所在目录（可选）", false).
+			Strings("include", "URL 必须匹配的正则列表（可选）", false).
+			Strings("exclude", "URL 排除的正则列表（可选）", false).
+			Number("parallelism", "每个域名的并发数，默认 2（可选）", false).
+			Bool("map_only", "只返回站点地图，不返回页面内容（可选）", false).
+			Bool("respect_robots", "遵守 robots.txt 的 Disallow 与 Crawl-delay（可选）", false).
+			With(fetchParams).
 			Handle(func(ctx *core.Context) *core.ToolResult {
-				repoURL := ctx.String("repo")
-				docsPath := ctx.String("path")
+				opts, err := fetchOptions(ctx)
+				if err != nil {
+					return ctx.Error("参数错误: " + err.Error())
+				}
+				crawler := tools.NewCrawler().
+					Limits(opts).
+					Include(ctx.Strings("include")...).
+					Exclude(ctx.Strings("exclude")...).
+					MapOnly(ctx.Bool("map_only")).
+					RespectRobots(ctx.Bool("respect_robots"))
+				if ctx.Has("depth") {
+					crawler.Depth(min(max(ctx.Int("depth"), 0), tools.CrawlDepthLimit))
+				}
+				if ctx.Has("max_pages") {
+					crawler.MaxPages(min(max(ctx.Int("max_pages"), 1), tools.CrawlPagesLimit))
+				}
+				if ctx.Has("parallelism") {
+					crawler.Parallelism(min(max(ctx.Int("parallelism"), 1), 4))
+				}
+				if scope := ctx.String("scope"); scope != "" {
+					crawler.Scope(scope)
+				}
+				if prefix := ctx.String("prefix"); prefix != "" {
+					crawler.Prefix(prefix)
+				}
 
-				var result *tools.DocsResult
-				var err error
+				result, err := crawler.Crawl(ctx.String("url"))
+				if err != nil {
+					return ctx.Error("爬取失败: " + err.Error())
+				}
+				return ctx.JSON(result)
+			}),
+	)
 
-				if docsPath != "" {
-					result, err = tools.QuickDownloadPath(repoURL, docsPath)
-				} else {
-					result, err = tools.QuickDownload(repoURL)
+	// 站点地图工具 - robots.txt / sitemap.xml 发现
+	server.Register(
+		core.NewTool("sitemap").
+			Desc("读取站点的 robots.txt 与 sitemap.xml（支持索引和 gzip），列出 URL 及其 lastmod、priority").
+			String("url", "站点 URL 或站点地图 URL", true).
+			String("prefix", "只保留路径以此开头的 URL，如 /docs/（可选）", false).
+			Number("limit", "最多返回的 URL 数，默认 1000，最大 5000（可选）", false).
+			Number("max_bytes", "单个站点地图下载与解压后的最大字节数，默认 5MB，最大 20MB（可选）", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := &tools.SitemapOptions{Prefix: ctx.String("prefix"), Limit: 1000}
+				if ctx.Has("limit") {
+					opts.Limit = ctx.Int("limit")
+				}
+				if ctx.Has("max_bytes") {
+					opts.MaxBytes = ctx.Int("max_bytes")
+				}
+				result, err := tools.DiscoverSitemap(ctx.String("url"), opts.Clamp())
+				if err != nil {
+					return ctx.Error("读取站点地图失败: " + err.Error())
 				}
+				return ctx.JSON(result)
+			}),
+	)
 
+	// 订阅源工具 - RSS / Atom / JSON Feed
+	server.Register(
+		core.NewTool("feed").
+			Desc("读取 RSS 2.0、Atom 或 JSON Feed 订阅源，传入网页时自动发现订阅源，返回条目列表").
+			String("url", "订阅源 URL 或包含订阅源链接的网页 URL", true).
+			String("since", "只返回此时间之后的条目，如 2024-01-01 或 RFC3339（可选）", false).
+			Number("limit", "最多返回的条目数，默认 20，最大 200（可选）", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				since, err := tools.ParseSince(ctx.String("since"))
+				if err != nil {
+					return ctx.Error("参数错误: " + err.Error())
+				}
+				limit := 20
+				if ctx.Has("limit") {
+					limit = min(max(ctx.Int("limit"), 1), tools.FeedLimit)
+				}
+				result, err := tools.ReadFeed(ctx.String("url"), &tools.FeedOptions{
+					Since: since,
+					Limit: limit,
+				})
+				if err != nil {
+					return ctx.Error("读取订阅源失败: " + err.Error())
+				}
+				return ctx.JSON(result)
+			}),
+	)
+
+	// 页面变更监控工具 - 保存快照并返回 diff
+	server.Register(
+		core.NewTool("watch_add").
+			Desc("开始监控网页，保存规范化后的 Markdown 快照与哈希").
+			String("url", "要监控的网页 URL", true).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				w, err := tools.AddWatch(ctx.String("url"))
+				if err != nil {
+					return ctx.Error("添加监控失败: " + err.Error())
+				}
+				return ctx.JSON(core.H{
+					"url":      w.URL,
+					"title":    w.Title,
+					"hash":     w.Hash,
+					"added_at": w.AddedAt,
+				})
+			}),
+	)
+
+	server.Register(
+		core.NewTool("watch_list").
+			Desc("列出所有监控中的网页及其最近检查与变更时间").
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				watches, err := tools.ListWatches()
+				if err != nil {
+					return ctx.Error("读取监控失败: " + err.Error())
+				}
+				return ctx.JSON(core.H{"count": len(watches), "watches": watches})
+			}),
+	)
+
+	server.Register(
+		core.NewTool("watch_check").
+			Desc("重新抓取监控中的网页，返回自上次快照以来的统一格式 diff").
+			String("url", "要检查的网页 URL，为空时检查最久未检查的页面（最多 10 个）", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				if url := ctx.String("url"); url != "" {
+					result, err := tools.CheckWatch(url)
+					if err != nil {
+						return ctx.Error("检查失败: " + err.Error())
+					}
+					return ctx.JSON(result)
+				}
+				results, err := tools.CheckAllWatches(tools.WatchCheckLimit)
+				if err != nil {
+					return ctx.Error("检查失败: " + err.Error())
+				}
+				return ctx.JSON(core.H{"count": len(results), "results": results})
+			}),
+	)
+
+	// GitHub 仓库文档下载工具
+	server.Register(
+		core.NewTool("download_docs").
+			Desc("从 Git 仓库下载文档文件（.md, .txt），返回文件内容").
+			With(docsParams).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				result, err := downloadDocs(ctx)
 				if err != nil {
 					return ctx.Error("下载失败: " + err.Error())
 				}
 
 				return ctx.JSON(core.H{
-					"repo_url": result.RepoURL,
-					"owner":    result.Owner,
-					"repo":     result.Repo,
-					"count":    result.Count,
-					"files":    result.Files,
+					"repo_url":        result.RepoURL,
+					"host":            result.Host,
+					"owner":           result.Owner,
+					"repo":            result.Repo,
+					"path":            result.Path,
+					"ref":             result.Ref,
+					"commit":          result.Commit,
+					"count":           result.Count,
+					"files":           result.Files,
+					"total_matched":   result.TotalMatched,
+					"offset":          result.Offset,
+					"next_offset":     result.NextOffset,
+					"truncated":       result.Truncated,
+					"skipped_reasons": result.SkippedReasons,
 				})
 			}),
 	)
@@ -180,27 +448,313 @@ func registerTools(server *core.Server) {
 	// GitHub 仓库文档下载工具 - 返回 Markdown 格式
 	server.Register(
 		core.NewTool("download_docs_md").
-			Desc("从 GitHub 仓库下载文档文件，返回合并的 Markdown 文本").
-			String("repo", "GitHub 仓库 URL，如 https://github.com/user/repo", true).
-			String("path", "文档路径过滤，如 docs（可选）", false).
+			Desc("从 Git 仓库下载文档文件，返回合并的 Markdown 文本").
+			With(docsParams).
+			With(chunkParams).
 			Handle(func(ctx *core.Context) *core.ToolResult {
-				repoURL := ctx.String("repo")
-				docsPath := ctx.String("path")
+				result, err := downloadDocs(ctx)
+				if err != nil {
+					return ctx.Error("下载失败: " + err.Error())
+				}
 
-				var result *tools.DocsResult
-				var err error
+				page, err := result.ToMarkdownChunk(chunkOptions(ctx), ctx.Int("page"))
+				if err != nil {
+					return ctx.Error("分页失败: " + err.Error())
+				}
+				return ctx.Markdown(page.Markdown())
+			}),
+	)
 
-				if docsPath != "" {
-					result, err = tools.QuickDownloadPath(repoURL, docsPath)
-				} else {
-					result, err = tools.QuickDownload(repoURL)
+	// 仓库目录树工具
+	server.Register(
+		core.NewTool("repo_tree").
+			Desc("列出 Git 仓库的目录树（路径、类型、大小、SHA），可按路径、深度与 glob 过滤").
+			With(repoParams).
+			String("path", "只列出该目录下的条目，如 docs（可选）", false).
+			Number("max_depth", "相对 path 的最大深度，默认不限（可选）", false).
+			Strings("include", "包含的 glob，如 **/*.go，匹配条目的上级目录也会列出（可选）", false).
+			Strings("exclude", "排除的 glob，如 vendor，匹配的目录不再展开（可选）", false).
+			Number("max_entries", "最大条目数，默认 1000，最大 5000（可选）", false).
+			String("format", "输出格式：text（默认，缩进目录树）或 json", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := repotools.DefaultTreeOptions()
+				opts.RepoURL = ctx.String("repo")
+				opts.Ref = ctx.String("ref")
+				opts.Path = ctx.String("path")
+				opts.MaxDepth = ctx.Int("max_depth")
+				opts.Include = ctx.Strings("include")
+				opts.Exclude = ctx.Strings("exclude")
+				if ctx.Has("max_entries") {
+					opts.MaxEntries = ctx.Int("max_entries")
 				}
 
+				result, err := repotools.ListTree(opts.Clamp())
 				if err != nil {
-					return ctx.Error("下载失败: " + err.Error())
+					return ctx.Error("列出目录失败: " + err.Error())
+				}
+				if ctx.String("format") == "json" {
+					return ctx.JSON(result)
+				}
+				return ctx.Text(result.ToText())
+			}),
+	)
+
+	// 仓库文件读取工具
+	server.Register(
+		core.NewTool("repo_read_file").
+			Desc("读取 Git 仓库中任意文件，文本文件按行范围返回带行号的内容，二进制文件返回嵌入资源").
+			With(repoParams).
+			String("path", "文件路径，如 cmd/main.go；repo 为 blob 链接时可省略", false).
+			Number("start_line", "起始行，从 1 开始，默认 1（可选）", false).
+			Number("end_line", "结束行（含），默认到文件末尾（可选）", false).
+			Number("max_bytes", "返回内容的最大字节数，默认 256KB，最大 1MB；二进制文件超过时报错（可选）", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := repotools.DefaultFileOptions()
+				opts.RepoURL = ctx.String("repo")
+				opts.Ref = ctx.String("ref")
+				opts.Path = ctx.String("path")
+				opts.StartLine = ctx.Int("start_line")
+				opts.EndLine = ctx.Int("end_line")
+				if ctx.Has("max_bytes") {
+					opts.MaxBytes = int64(ctx.Int("max_bytes"))
+				}
+
+				result, err := repotools.ReadFile(opts.Clamp())
+				if err != nil {
+					return ctx.Error("读取文件失败: " + err.Error())
+				}
+				if result.Binary {
+					return ctx.Blob(result.URI(), result.MimeType, result.Data)
+				}
+				return ctx.Text(result.ToText())
+			}),
+	)
+
+	// 仓库内容搜索工具
+	server.Register(
+		core.NewTool("repo_grep").
+			Desc("在 Git 仓库的文件内容中搜索正则或字面量，返回 file:line:text 格式的匹配行").
+			With(repoParams).
+			String("pattern", "搜索模式，默认为 RE2 正则表达式", true).
+			Bool("literal", "按字面量匹配，不解析正则（可选）", false).
+			Bool("ignore_case", "忽略大小写（可选）", false).
+			String("path", "只搜索该路径下的文件，如 src（可选）", false).
+			Strings("include", "包含的 glob，如 **/*.go；不含 / 的模式匹配任意目录下的文件名（可选）", false).
+			Strings("exclude", "排除的 glob，如 vendor/**（可选）", false).
+			Number("context", "匹配行前后的上下文行数，默认 0，最大 10（可选）", false).
+			Number("max_matches", "最大匹配行数，默认 100，最大 1000（可选）", false).
+			String("format", "输出格式：text（默认）或 json", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := repotools.DefaultGrepOptions()
+				opts.RepoURL = ctx.String("repo")
+				opts.Ref = ctx.String("ref")
+				opts.Path = ctx.String("path")
+				opts.Pattern = ctx.String("pattern")
+				opts.Literal = ctx.Bool("literal")
+				opts.IgnoreCase = ctx.Bool("ignore_case")
+				opts.Include = ctx.Strings("include")
+				opts.Exclude = ctx.Strings("exclude")
+				opts.Context = ctx.Int("context")
+				if ctx.Has("max_matches") {
+					opts.MaxMatches = ctx.Int("max_matches")
+				}
+
+				result, err := repotools.Grep(opts.Clamp())
+				if err != nil {
+					return ctx.Error("搜索失败: " + err.Error())
+				}
+				if ctx.String("format") == "json" {
+					return ctx.JSON(result)
+				}
+				return ctx.Text(result.ToText())
+			}),
+	)
+
+	// 仓库提交历史工具
+	server.Register(
+		core.NewTool("repo_log").
+			Desc("列出 Git 仓库的提交历史（作者、日期、提交信息），可按路径、时间与作者过滤").
+			With(repoParams).
+			With(historyParams).
+			String("path", "只列出修改了该文件或目录的提交（可选）", false).
+			String("since", "起始时间，YYYY-MM-DD 或 RFC 3339（可选）", false).
+			String("until", "截止时间，YYYY-MM-DD 表示包含当天（可选）", false).
+			String("author", "作者名或邮箱包含的文本，忽略大小写（可选）", false).
+			Number("max_count", "最大提交数，默认 50，最大 500（可选）", false).
+			String("format", "输出格式：text（默认）或 json", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := repotools.DefaultLogOptions()
+				opts.RepoURL = ctx.String("repo")
+				opts.Ref = ctx.String("ref")
+				opts.Path = ctx.String("path")
+				opts.Since = ctx.String("since")
+				opts.Until = ctx.String("until")
+				opts.Author = ctx.String("author")
+				if ctx.Has("max_count") {
+					opts.MaxCount = ctx.Int("max_count")
+				}
+				if ctx.Has("depth") {
+					opts.Depth = ctx.Int("depth")
+				}
+
+				result, err := repotools.Log(opts.Clamp())
+				if err != nil {
+					return ctx.Error("获取提交历史失败: " + err.Error())
+				}
+				if ctx.String("format") == "json" {
+					return ctx.JSON(result)
+				}
+				return ctx.Text(result.ToText())
+			}),
+	)
+
+	// 仓库逐行作者工具
+	server.Register(
+		core.NewTool("repo_blame").
+			Desc("查看 Git 仓库中文件每一行最后修改的提交、作者与日期").
+			With(repoParams).
+			With(historyParams).
+			String("path", "文件路径；repo 为 blob 链接时可省略", false).
+			Number("start_line", "起始行，从 1 开始，默认 1（可选）", false).
+			Number("end_line", "结束行（含），默认到文件末尾（可选）", false).
+			String("format", "输出格式：text（默认）或 json", false).
+			Handle(func(ctx *core.Context) *core.ToolResult {
+				opts := repotools.DefaultBlameOptions()
+				opts.RepoURL = ctx.String("repo")
+				opts.Ref = ctx.String("ref")
+				opts.Path = ctx.String("path")
+				opts.StartLine = ctx.Int("start_line")
+				opts.EndLine = ctx.Int("end_line")
+				if ctx.Has("depth") {
+					opts.Depth = ctx.Int("depth")
 				}
 
-				return ctx.Markdown(result.ToMarkdown())
+				result, err := repotools.Blame(opts.Clamp())
+				if err != nil {
+					return ctx.Error("获取逐行作者失败: " + err.Error())
+				}
+				if ctx.String("format") == "json" {
+					return ctx.JSON(result)
+				}
+				return ctx.Text(result.ToText())
 			}),
 	)
 }
+
+// fetchParams 抓取类工具共用的大小、时限、重定向、类型限制、缓存、重试与认证参数
+func fetchParams(t *core.Tool) *core.Tool {
+	return t.
+		Number("max_bytes", "响应体最大字节数，默认 5MB，最大 20MB（可选）", false).
+		Number("timeout", "总时限（秒），默认 10，最大 25（可选）", false).
+		Number("max_redirects", "最大重定向次数，默认 5，最大 10（可选）", false).
+		Strings("content_types", "允许的 Content-Type 前缀，如 text/html（可选）", false).
+		Bool("no_cache", "跳过响应缓存，强制重新抓取（可选）", false).
+		Number("retries", "429 / 5xx 的最大重试次数，默认 2，最大 5（可选）", false).
+		Strings("headers", "附加请求头，如 Authorization: Bearer xxx，需服务端开启（可选）", false).
+		Strings("cookies", "附加 Cookie，如 session=xxx，需服务端开启（可选）", false).
+		String("basic_auth", "Basic 认证，格式 user:password，需服务端开启（可选）", false)
+}
+
+// fetchOptions 读取抓取参数，并按服务端上限裁剪；调用方传入的认证信息只发往 url 的主机
+func fetchOptions(ctx *core.Context) (*tools.ScraperOptions, error) {
+	opts := tools.DefaultScraperOptions()
+	if ctx.Has("max_bytes") {
+		opts.MaxBodySize = ctx.Int("max_bytes")
+	}
+	if ctx.Has("timeout") {
+		opts.Timeout = time.Duration(ctx.Float("timeout") * float64(time.Second))
+	}
+	if ctx.Has("max_redirects") {
+		opts.MaxRedirects = ctx.Int("max_redirects")
+	}
+	opts.ContentTypes = ctx.Strings("content_types")
+	if ctx.Bool("no_cache") {
+		opts.Cache = nil
+	}
+	if ctx.Has("retries") {
+		opts.Retries = ctx.Int("retries")
+	}
+
+	auth, err := tools.ParseCallAuth(ctx.Strings("headers"), ctx.Strings("cookies"), ctx.String("basic_auth"))
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		// 带调用方凭据的响应不进入共享缓存
+		opts.Auth = opts.Auth.With(ctx.String("url"), auth)
+		opts.Cache = nil
+	}
+	return opts.Clamp(), nil
+}
+
+// repoParams 仓库类工具共用的仓库地址与版本参数
+func repoParams(t *core.Tool) *core.Tool {
+	return t.
+		String("repo", "仓库 URL，支持 GitHub、GitLab、Gitea、Bitbucket 的仓库或 /tree/<ref>/<path> 浏览器链接及 git@ 地址", true).
+		String("ref", "分支、标签或提交 SHA（可选，默认为默认分支）", false)
+}
+
+// historyParams 提交历史类工具共用的历史深度参数
+func historyParams(t *core.Tool) *core.Tool {
+	return t.
+		Number("depth", "遍历的历史深度（提交代数），超出部分视为边界，默认 200，最大 2000（可选）", false)
+}
+
+// docsParams 仓库文档下载工具共用的参数
+func docsParams(t *core.Tool) *core.Tool {
+	return t.
+		With(repoParams).
+		String("path", "文档路径过滤，如 docs（可选）", false).
+		Strings("extensions", "文件扩展名，如 .rst，默认 .md 与 .txt（可选）", false).
+		Strings("include", "包含的 glob，如 docs/**/*.md，设置后不再按扩展名过滤；不含 / 的模式匹配任意目录下的文件名（可选）", false).
+		Strings("exclude", "排除的 glob，如 **/CHANGELOG.md（可选）", false).
+		Number("max_files", "最大文件数，默认 50，最大 500（可选）", false).
+		Number("offset", "跳过排序后的前 N 个匹配文件，传入上次结果的 next_offset 继续下载（可选）", false).
+		Number("max_file_bytes", "单个文件的最大字节数，超过的文件跳过，默认 256KB，最大 1MB（可选）", false).
+		Number("max_total_bytes", "全部文件的总字节预算，默认 2MB，最大 8MB（可选）", false)
+}
+
+// docsOptions 读取仓库文档下载参数，并按服务端上限裁剪
+func docsOptions(ctx *core.Context) *repotools.DownhubOptions {
+	opts := repotools.DefaultOptions()
+	opts.RepoURL = ctx.String("repo")
+	opts.DocsPath = ctx.String("path")
+	opts.Ref = ctx.String("ref")
+	if ctx.Has("extensions") {
+		opts.Extensions = ctx.Strings("extensions")
+	}
+	opts.Include = ctx.Strings("include")
+	opts.Exclude = ctx.Strings("exclude")
+	if ctx.Has("max_files") {
+		opts.MaxFiles = ctx.Int("max_files")
+	}
+	opts.Offset = ctx.Int("offset")
+	if ctx.Has("max_file_bytes") {
+		opts.MaxFileSize = int64(ctx.Int("max_file_bytes"))
+	}
+	if ctx.Has("max_total_bytes") {
+		opts.MaxTotalBytes = int64(ctx.Int("max_total_bytes"))
+	}
+	return opts.Clamp()
+}
+
+// downloadDocs 按工具参数下载仓库文档
+func downloadDocs(ctx *core.Context) (*repotools.DocsResult, error) {
+	return repotools.NewDownhub().WithOptions(docsOptions(ctx)).Fetch()
+}
+
+// chunkParams 按 token 预算分页的参数
+func chunkParams(t *core.Tool) *core.Tool {
+	return t.
+		Number("max_chars", "每页最多字符数，按标题与段落边界切分（可选）", false).
+		Number("max_tokens", "每页最多 token 数（估算，可选）", false).
+		Number("page", "页码，从 1 开始，取上次结果中的 next 继续（可选）", false)
+}
+
+// chunkOptions 读取分页参数，并按服务端上下限裁剪
+func chunkOptions(ctx *core.Context) chunk.Options {
+	return chunk.Options{
+		MaxChars:  ctx.Int("max_chars"),
+		MaxTokens: ctx.Int("max_tokens"),
+	}.Clamp()
+}
@@ -1,6 +1,9 @@
 package mcp
 
-import "encoding/json"
+import (
+	"encoding/base64"
+	"encoding/json"
+)
 
 // H 类似 gin.H 的灵活 map 类型
 type H map[string]any
@@ -44,6 +47,25 @@ func (c *Context) Bool(key string) bool {
 	return false
 }
 
+// Strings 获取字符串数组参数，单个字符串视为一个元素
+func (c *Context) Strings(key string) []string {
+	switch v := c.Arguments[key].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
 // Has 检查参数是否存在
 func (c *Context) Has(key string) bool {
 	_, ok := c.Arguments[key]
@@ -75,6 +97,17 @@ func (c *Context) Markdown(md string) *ToolResult {
 	}
 }
 
+// Blob 返回嵌入的二进制资源
+func (c *Context) Blob(uri, mimeType string, data []byte) *ToolResult {
+	return &ToolResult{
+		Content: []Content{{Type: "resource", Resource: &ResourceContents{
+			URI:      uri,
+			MimeType: mimeType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		}}},
+	}
+}
+
 // Error 返回错误结果
 func (c *Context) Error(msg string) *ToolResult {
 	return &ToolResult{
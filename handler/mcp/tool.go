@@ -54,6 +54,20 @@ func (t *Tool) Bool(name, desc string, required bool) *Tool {
 	return t
 }
 
+// Strings 添加字符串数组参数
+func (t *Tool) Strings(name, desc string, required bool) *Tool {
+	t.properties[name] = Property{Type: "array", Description: desc, Items: &Property{Type: "string"}}
+	if required {
+		t.required = append(t.required, name)
+	}
+	return t
+}
+
+// With 应用一组可复用的参数定义
+func (t *Tool) With(fn func(*Tool) *Tool) *Tool {
+	return fn(t)
+}
+
 // Handle 设置处理函数
 func (t *Tool) Handle(h ToolHandler) *Tool {
 	t.handler = h
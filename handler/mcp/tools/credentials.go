@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"mcp-server/internal/outbound"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// defaultTokenUser 只提供令牌时使用的 HTTP 用户名，GitHub、GitLab 与 Gitea 均接受任意非空用户名
+const defaultTokenUser = "x-access-token"
+
+// GitCredential 访问某个 Git 主机的凭据
+type GitCredential struct {
+	Username   string `json:"username,omitempty"`   // HTTP 用户名，SSH 时为登录用户（默认取自 URL 或 git）
+	Token      string `json:"token,omitempty"`      // HTTP 令牌或密码
+	SSHKey     string `json:"ssh_key,omitempty"`    // SSH 私钥，PEM 文本或文件路径
+	Passphrase string `json:"passphrase,omitempty"` // 私钥口令
+}
+
+// GitCredentials 按主机名匹配的凭据
+type GitCredentials map[string]*GitCredential
+
+// defaultCredentials 进程级默认凭据
+var defaultCredentials GitCredentials
+
+// SetDefaultCredentials 设置默认凭据
+func SetDefaultCredentials(c GitCredentials) {
+	defaultCredentials = c
+}
+
+// CredentialsFromEnv 读取 GIT_CREDENTIALS（JSON 文本或 JSON 文件路径），
+// GITHUB_TOKEN 在未单独配置 github.com 时作为其令牌
+func CredentialsFromEnv() (GitCredentials, error) {
+	creds := GitCredentials{}
+
+	if raw := strings.TrimSpace(os.Getenv("GIT_CREDENTIALS")); raw != "" {
+		data := []byte(raw)
+		if !strings.HasPrefix(raw, "{") {
+			var err error
+			if data, err = os.ReadFile(raw); err != nil {
+				return nil, fmt.Errorf("read git credentials failed: %w", err)
+			}
+		}
+		if err := json.Unmarshal(data, &creds); err != nil {
+			return nil, fmt.Errorf("parse git credentials failed: %w", err)
+		}
+	}
+
+	if token := strings.TrimSpace(os.Getenv("GITHUB_TOKEN")); token != "" {
+		if _, ok := creds["github.com"]; !ok {
+			creds["github.com"] = &GitCredential{Token: token}
+		}
+	}
+	return creds, nil
+}
+
+// remote 克隆使用的远程地址与认证
+type remote struct {
+	url     string // 去掉凭据后的 URL
+	auth    transport.AuthMethod
+	secrets []string // 需要从错误信息中去除的凭据
+	// authKey 调用方在 URL 中传入的凭据摘要，用于隔离克隆缓存，使用服务端凭据时为空
+	authKey string
+}
+
+// newRemote 解析仓库地址，检查出站策略并按主机选择凭据；URL 中的用户名密码优先于配置，
+// 配置的凭据只用于 https 与 ssh
+func newRemote(rawURL string) (*remote, error) {
+	ep, err := transport.NewEndpoint(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL: %s", redactURL(rawURL))
+	}
+
+	rm := &remote{}
+	if ep.Password != "" {
+		rm.secrets = append(rm.secrets, ep.Password)
+	}
+	cred := defaultCredentials[strings.ToLower(ep.Host)]
+
+	switch ep.Protocol {
+	case "http", "https":
+		user, pass := ep.User, ep.Password
+		if user != "" && pass == "" {
+			// https://<token>@host/... 形式，用户名即令牌
+			user, pass = defaultTokenUser, user
+			rm.secrets = append(rm.secrets, pass)
+		}
+		if pass != "" {
+			sum := sha256.Sum256([]byte(user + ":" + pass))
+			rm.authKey = hex.EncodeToString(sum[:])
+		}
+		ep.User, ep.Password = "", ""
+		rm.url = ep.String()
+		if err := outbound.CheckURL(rm.url); err != nil {
+			return nil, err
+		}
+
+		// 服务端配置的凭据只通过 https 发送，避免调用方用 http:// 地址诱使令牌明文传输
+		if pass == "" && cred != nil && cred.Token != "" && ep.Protocol == "https" {
+			user, pass = cred.Username, cred.Token
+			if user == "" {
+				user = defaultTokenUser
+			}
+		}
+		if pass != "" {
+			rm.auth = &githttp.BasicAuth{Username: user, Password: pass}
+			rm.secrets = append(rm.secrets, pass)
+		}
+	case "ssh":
+		ep.Password = ""
+		rm.url = ep.String()
+		if err := outbound.CheckHost(context.Background(), ep.Host); err != nil {
+			return nil, err
+		}
+
+		user := ep.User
+		if user == "" && cred != nil {
+			user = cred.Username
+		}
+		if user == "" {
+			user = "git"
+		}
+		// 未配置私钥时由 go-git 使用 SSH_AUTH_SOCK 中的 ssh-agent
+		if cred != nil && cred.SSHKey != "" {
+			if rm.auth, err = sshKeyAuth(user, cred); err != nil {
+				return nil, err
+			}
+			rm.secrets = append(rm.secrets, cred.Passphrase)
+		} else if rm.auth, err = gitssh.NewSSHAgentAuth(user); err != nil {
+			rm.auth = nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: scheme %q is not allowed", outbound.ErrBlocked, ep.Protocol)
+	}
+	return rm, nil
+}
+
+// sshKeyAuth 读取 PEM 文本或文件中的私钥
+func sshKeyAuth(user string, cred *GitCredential) (transport.AuthMethod, error) {
+	pem := []byte(cred.SSHKey)
+	if !strings.HasPrefix(strings.TrimSpace(cred.SSHKey), "-----BEGIN") {
+		var err error
+		if pem, err = os.ReadFile(cred.SSHKey); err != nil {
+			return nil, fmt.Errorf("read ssh key failed: %w", err)
+		}
+	}
+	auth, err := gitssh.NewPublicKeys(user, pem, cred.Passphrase)
+	if err != nil {
+		// 解析错误不包含私钥内容，但可能提及口令错误，统一成固定信息
+		return nil, fmt.Errorf("load ssh key failed: invalid key or passphrase")
+	}
+	return auth, nil
+}
+
+// redact 去除错误信息中的凭据，保留原错误以便 errors.Is 判断
+func (rm *remote) redact(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redacted := msg
+	for _, s := range rm.secrets {
+		if s != "" {
+			redacted = strings.ReplaceAll(redacted, s, "***")
+		}
+	}
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{msg: redacted, err: err}
+}
+
+// redactedError 去除了凭据的错误
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactURL 去除 URL 中的用户信息，用于无法解析的地址
+func redactURL(rawURL string) string {
+	if at := strings.LastIndex(rawURL, "@"); at >= 0 {
+		if scheme := strings.Index(rawURL, "://"); scheme >= 0 && scheme < at {
+			return rawURL[:scheme+3] + "***" + rawURL[at:]
+		}
+	}
+	return rawURL
+}
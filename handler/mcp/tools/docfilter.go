@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// docAttr GitHub Linguist 标记文档文件的属性
+const docAttr = "linguist-documentation"
+
+// docFilter 按路径、扩展名、glob 与 .gitattributes 判断文件是否为文档
+type docFilter struct {
+	path       string
+	extensions []string
+	include    []string
+	exclude    []string
+	attrs      gitattributes.Matcher // 仓库中没有 .gitattributes 时为 nil
+}
+
+// newDocFilter 校验 glob 并读取 tree 中的全部 .gitattributes
+func newDocFilter(opts *DownhubOptions, docsPath string, tree *object.Tree) (*docFilter, error) {
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("invalid glob pattern: %q", p)
+		}
+	}
+
+	f := &docFilter{
+		path:       docsPath,
+		extensions: opts.Extensions,
+		include:    opts.Include,
+		exclude:    opts.Exclude,
+	}
+	attrs, err := readAttributes(tree)
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) > 0 {
+		f.attrs = gitattributes.NewMatcher(attrs)
+	}
+	return f, nil
+}
+
+// readAttributes 读取 tree 中的 .gitattributes，按目录深度排列，深层文件优先级更高
+func readAttributes(tree *object.Tree) ([]gitattributes.MatchAttribute, error) {
+	var files []*object.File
+	err := tree.Files().ForEach(func(f *object.File) error {
+		if path.Base(f.Name) == ".gitattributes" {
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk tree failed: %w", err)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.Count(files[i].Name, "/") < strings.Count(files[j].Name, "/")
+	})
+
+	var attrs []gitattributes.MatchAttribute
+	for _, f := range files {
+		r, err := f.Reader()
+		if err != nil {
+			continue
+		}
+		var domain []string
+		if dir := path.Dir(f.Name); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		// 与 git 一致，只有根目录的 .gitattributes 允许定义宏；格式错误的行之前的规则仍然生效
+		parsed, _ := gitattributes.ReadAttributes(r, domain, domain == nil)
+		r.Close()
+		attrs = append(attrs, parsed...)
+	}
+	return attrs, nil
+}
+
+// match 判断文件是否应该包含：exclude 优先；设置了 include 时只按 glob 判断，
+// 否则先看 linguist-documentation 标记，再按扩展名判断
+func (f *docFilter) match(name string) bool {
+	if f.path != "" && !strings.HasPrefix(name, f.path+"/") && name != f.path {
+		return false
+	}
+	if matchGlobs(f.exclude, name) {
+		return false
+	}
+	if len(f.include) > 0 {
+		return matchGlobs(f.include, name)
+	}
+
+	if f.attrs != nil {
+		if attrs, ok := f.attrs.Match(strings.Split(name, "/"), []string{docAttr}); ok {
+			if a, ok := attrs[docAttr]; ok {
+				switch {
+				case a.IsSet() || a.IsValueSet() && a.Value() == "true":
+					return true
+				case a.IsUnset() || a.IsValueSet() && a.Value() == "false":
+					return false
+				}
+			}
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range f.extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlobs 判断路径是否匹配任一 glob；不含 / 的模式匹配任意目录下的文件名，与 .gitignore 一致
+func matchGlobs(patterns []string, name string) bool {
+	for _, p := range patterns {
+		target := name
+		if !strings.Contains(p, "/") {
+			target = path.Base(name)
+		}
+		if ok, _ := doublestar.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortDocs README 与 index 文件排在最前（浅层优先），其余按路径排序
+func sortDocs(files []*object.File) {
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i].Name, files[j].Name
+		ia, ib := isIndexDoc(a), isIndexDoc(b)
+		if ia != ib {
+			return ia
+		}
+		if da, db := strings.Count(a, "/"), strings.Count(b, "/"); ia && da != db {
+			return da < db
+		}
+		return a < b
+	})
+}
+
+// isIndexDoc 判断是否为 README 或 index 文件
+func isIndexDoc(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	return base == "readme" || base == "index" || base == "_index"
+}
@@ -2,14 +2,24 @@ package tools
 
 import (
 	"fmt"
-	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/go-git/go-git/v5"
+	"mcp-server/internal/chunk"
+	"mcp-server/internal/outbound"
+
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+func init() {
+	// go-git 的 HTTP(S) 传输同样经过出站策略
+	gitClient := githttp.NewClient(outbound.Default().Client())
+	client.InstallProtocol("https", gitClient)
+	client.InstallProtocol("http", gitClient)
+}
+
 // DocFile 文档文件
 type DocFile struct {
 	Path    string `json:"path"`
@@ -19,26 +29,82 @@ type DocFile struct {
 // DocsResult 文档下载结果
 type DocsResult struct {
 	RepoURL string    `json:"repo_url"`
+	Host    string    `json:"host"`
 	Owner   string    `json:"owner"`
 	Repo    string    `json:"repo"`
+	Path    string    `json:"path,omitempty"` // 文档路径过滤
+	Ref     string    `json:"ref,omitempty"`  // 解析出的分支或标签名
+	Commit  string    `json:"commit"`         // 实际下载的提交 SHA
 	Files   []DocFile `json:"files"`
 	Count   int       `json:"count"`
+
+	TotalMatched int  `json:"total_matched"`         // 匹配过滤条件的文件总数
+	Offset       int  `json:"offset"`                // 本页起始位置
+	NextOffset   int  `json:"next_offset,omitempty"` // 下一页的 offset，未截断时为 0
+	Truncated    bool `json:"truncated"`             // 是否还有文件因文件数或总预算未返回
+	// SkippedReasons 按原因统计未返回的文件：too_large、binary、unreadable、budget、max_files
+	SkippedReasons map[string]int `json:"skipped_reasons,omitempty"`
 }
 
+// 文件未返回的原因
+const (
+	skipTooLarge   = "too_large"
+	skipBinary     = "binary"
+	skipUnreadable = "unreadable"
+	skipBudget     = "budget"
+	skipMaxFiles   = "max_files"
+)
+
+// 下载的服务端上限
+const (
+	MaxFilesLimit   = 500
+	FileSizeLimit   = 1 << 20
+	TotalBytesLimit = 8 << 20
+)
+
 // DownhubOptions 下载选项
 type DownhubOptions struct {
-	RepoURL    string   // GitHub 仓库 URL
-	DocsPath   string   // 文档路径（可选，如 "docs"）
-	Extensions []string // 文件扩展名过滤（默认 .md, .txt）
-	MaxFiles   int      // 最大文件数（防止过多）
+	RepoURL       string   // 仓库 URL，支持 HTTPS、SSH（git@host:owner/repo）与 /tree/<ref>/<path> 等浏览器链接
+	DocsPath      string   // 文档路径（可选，如 "docs"）
+	Ref           string   // 分支、标签或提交 SHA（可选，默认为默认分支）
+	Extensions    []string // 文件扩展名过滤（默认 .md, .txt），设置 Include 时不再使用
+	Include       []string // 包含的 glob，如 docs/**/*.md；不含 / 的模式匹配任意目录下的文件名
+	Exclude       []string // 排除的 glob，优先于其他规则
+	MaxFiles      int      // 最大文件数（防止过多）
+	Offset        int      // 跳过排序后的前若干个匹配文件，用于分页
+	MaxFileSize   int64    // 单个文件的最大字节数，超过的文件跳过
+	MaxTotalBytes int64    // 全部文件的总字节预算
 }
 
 // DefaultOptions 默认选项
 func DefaultOptions() *DownhubOptions {
 	return &DownhubOptions{
-		Extensions: []string{".md", ".txt"},
-		MaxFiles:   50,
+		Extensions:    []string{".md", ".txt"},
+		MaxFiles:      50,
+		MaxFileSize:   256 << 10,
+		MaxTotalBytes: 2 << 20,
+	}
+}
+
+// Clamp 按服务端上限裁剪选项，并规范化扩展名，用于处理工具调用传入的参数
+func (o *DownhubOptions) Clamp() *DownhubOptions {
+	o.MaxFiles = min(max(o.MaxFiles, 1), MaxFilesLimit)
+	o.Offset = max(o.Offset, 0)
+	o.MaxFileSize = min(max(o.MaxFileSize, 1), FileSizeLimit)
+	o.MaxTotalBytes = min(max(o.MaxTotalBytes, 1), TotalBytesLimit)
+
+	exts := make([]string, 0, len(o.Extensions))
+	for _, e := range o.Extensions {
+		if e = strings.ToLower(strings.TrimSpace(e)); e == "" {
+			continue
+		}
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		exts = append(exts, e)
 	}
+	o.Extensions = exts
+	return o
 }
 
 // Downhub 文档下载器
@@ -51,6 +117,12 @@ func NewDownhub() *Downhub {
 	return &Downhub{opts: DefaultOptions()}
 }
 
+// WithOptions 使用指定选项
+func (d *Downhub) WithOptions(opts *DownhubOptions) *Downhub {
+	d.opts = opts
+	return d
+}
+
 // URL 设置仓库 URL
 func (d *Downhub) URL(url string) *Downhub {
 	d.opts.RepoURL = url
@@ -63,128 +135,207 @@ func (d *Downhub) Path(path string) *Downhub {
 	return d
 }
 
+// Ref 设置分支、标签或提交 SHA
+func (d *Downhub) Ref(ref string) *Downhub {
+	d.opts.Ref = strings.TrimSpace(ref)
+	return d
+}
+
 // Extensions 设置文件扩展名
 func (d *Downhub) Extensions(exts ...string) *Downhub {
 	d.opts.Extensions = exts
 	return d
 }
 
+// Include 设置包含的 glob
+func (d *Downhub) Include(patterns ...string) *Downhub {
+	d.opts.Include = patterns
+	return d
+}
+
+// Exclude 设置排除的 glob
+func (d *Downhub) Exclude(patterns ...string) *Downhub {
+	d.opts.Exclude = patterns
+	return d
+}
+
 // MaxFiles 设置最大文件数
 func (d *Downhub) MaxFiles(n int) *Downhub {
 	d.opts.MaxFiles = n
 	return d
 }
 
+// Offset 设置分页起始位置
+func (d *Downhub) Offset(n int) *Downhub {
+	d.opts.Offset = n
+	return d
+}
+
+// MaxFileSize 设置单个文件的最大字节数
+func (d *Downhub) MaxFileSize(n int64) *Downhub {
+	d.opts.MaxFileSize = n
+	return d
+}
+
+// MaxTotalBytes 设置总字节预算
+func (d *Downhub) MaxTotalBytes(n int64) *Downhub {
+	d.opts.MaxTotalBytes = n
+	return d
+}
+
 // Fetch 执行下载
 func (d *Downhub) Fetch() (*DocsResult, error) {
 	if d.opts.RepoURL == "" {
 		return nil, fmt.Errorf("repository URL is required")
 	}
+	target, err := openRepo(d.opts.RepoURL, d.opts.Ref, d.opts.DocsPath)
+	if err != nil {
+		return nil, err
+	}
+	co := target.co
 
 	result := &DocsResult{
-		RepoURL: d.opts.RepoURL,
+		RepoURL: target.rm.url,
+		Host:    target.spec.Host,
+		Owner:   target.spec.Owner,
+		Repo:    target.spec.Repo,
+		Path:    target.path,
+		Ref:     co.ref,
+		Commit:  co.commit.Hash.String(),
 		Files:   []DocFile{},
 	}
 
-	// 解析 owner 和 repo
-	if strings.Contains(d.opts.RepoURL, "github.com/") {
-		parts := strings.Split(d.opts.RepoURL, "github.com/")
-		if len(parts) > 1 {
-			repoParts := strings.Split(strings.TrimSuffix(parts[1], ".git"), "/")
-			if len(repoParts) >= 2 {
-				result.Owner = repoParts[0]
-				result.Repo = repoParts[1]
-			}
-		}
-	}
-
-	// 克隆仓库到内存
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		URL:   d.opts.RepoURL,
-		Depth: 1,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("clone failed: %w", err)
-	}
-
-	// 获取 HEAD
-	ref, err := r.Head()
+	// 获取 tree
+	tree, err := co.commit.Tree()
 	if err != nil {
-		return nil, fmt.Errorf("get HEAD failed: %w", err)
+		return nil, fmt.Errorf("get tree failed: %w", err)
 	}
 
-	// 获取 commit
-	commit, err := r.CommitObject(ref.Hash())
+	filter, err := newDocFilter(d.opts, result.Path, tree)
 	if err != nil {
-		return nil, fmt.Errorf("get commit failed: %w", err)
+		return nil, err
 	}
 
-	// 获取 tree
-	tree, err := commit.Tree()
+	// 收集匹配的文件并排序，保证分页结果稳定
+	var matched []*object.File
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if filter.match(f.Name) {
+			matched = append(matched, f)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("get tree failed: %w", err)
+		return nil, fmt.Errorf("walk tree failed: %w", err)
 	}
+	sortDocs(matched)
+	result.TotalMatched = len(matched)
+	result.Offset = min(d.opts.Offset, len(matched))
 
-	// 遍历文件
-	err = tree.Files().ForEach(func(f *object.File) error {
+	var total int64
+	for i := result.Offset; i < len(matched); i++ {
+		f := matched[i]
 		if len(result.Files) >= d.opts.MaxFiles {
-			return nil
+			result.truncate(i, skipMaxFiles)
+			break
 		}
-
-		if d.shouldInclude(f.Name) {
-			content, err := f.Contents()
-			if err != nil {
-				return nil
+		// 超过单文件上限的文件与二进制文件跳过
+		if d.opts.MaxFileSize > 0 && f.Size > d.opts.MaxFileSize {
+			result.skip(skipTooLarge, 1)
+			continue
+		}
+		if bin, err := f.IsBinary(); err != nil || bin {
+			result.skip(skipBinary, 1)
+			continue
+		}
+		if d.opts.MaxTotalBytes > 0 && total+f.Size > d.opts.MaxTotalBytes {
+			// 单个文件就超出预算时跳过，否则留到下一页
+			if len(result.Files) == 0 {
+				result.skip(skipBudget, 1)
+				continue
 			}
-			result.Files = append(result.Files, DocFile{
-				Path:    f.Name,
-				Content: content,
-			})
+			result.truncate(i, skipBudget)
+			break
 		}
-		return nil
-	})
 
-	if err != nil {
-		return nil, fmt.Errorf("walk tree failed: %w", err)
+		content, err := f.Contents()
+		if err != nil {
+			result.skip(skipUnreadable, 1)
+			continue
+		}
+		total += f.Size
+		result.Files = append(result.Files, DocFile{
+			Path:    f.Name,
+			Content: content,
+		})
 	}
 
 	result.Count = len(result.Files)
 	return result, nil
 }
 
-// shouldInclude 判断文件是否应该包含
-func (d *Downhub) shouldInclude(filename string) bool {
-	// 检查路径前缀
-	if d.opts.DocsPath != "" {
-		if !strings.HasPrefix(filename, d.opts.DocsPath+"/") && filename != d.opts.DocsPath {
-			return false
-		}
+// skip 记录未返回的文件
+func (r *DocsResult) skip(reason string, n int) {
+	if r.SkippedReasons == nil {
+		r.SkippedReasons = make(map[string]int)
 	}
+	r.SkippedReasons[reason] += n
+}
 
-	// 检查扩展名
-	ext := strings.ToLower(filepath.Ext(filename))
-	for _, e := range d.opts.Extensions {
-		if ext == e {
-			return true
-		}
-	}
-	return false
+// truncate 在第 next 个匹配文件处截断，剩余文件计入 reason
+func (r *DocsResult) truncate(next int, reason string) {
+	r.Truncated = true
+	r.NextOffset = next
+	r.skip(reason, r.TotalMatched-next)
 }
 
 // ToMarkdown 将结果转换为 Markdown 格式
 func (r *DocsResult) ToMarkdown() string {
+	return r.render(2000)
+}
+
+// ToMarkdownChunk 将结果转换为 Markdown 并按选项分块，返回第 page 页；
+// 分块时不再截断单个文件，由分页承载全部内容
+func (r *DocsResult) ToMarkdownChunk(opts chunk.Options, page int) (*chunk.Chunk, error) {
+	if !opts.Enabled() {
+		return chunk.Page(r.ToMarkdown(), opts, page)
+	}
+	return chunk.Page(r.render(0), opts, page)
+}
+
+// render 生成 Markdown，limit 大于 0 时截断过长的文件内容
+func (r *DocsResult) render(limit int) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("# %s/%s\n\n", r.Owner, r.Repo))
+	if r.Owner != "" {
+		sb.WriteString(fmt.Sprintf("# %s/%s\n\n", r.Owner, r.Repo))
+	} else {
+		sb.WriteString(fmt.Sprintf("# %s\n\n", r.Repo))
+	}
+	if r.Ref != "" {
+		sb.WriteString(fmt.Sprintf("版本: %s (%s)\n\n", r.Ref, r.Commit))
+	} else if r.Commit != "" {
+		sb.WriteString(fmt.Sprintf("版本: %s\n\n", r.Commit))
+	}
 	sb.WriteString(fmt.Sprintf("共 %d 个文档文件\n\n", r.Count))
+	if r.Truncated {
+		sb.WriteString(fmt.Sprintf("结果已截断：共匹配 %d 个文件，传入 offset=%d 继续下载\n\n", r.TotalMatched, r.NextOffset))
+	}
+	if len(r.SkippedReasons) > 0 {
+		reasons := make([]string, 0, len(r.SkippedReasons))
+		for reason, n := range r.SkippedReasons {
+			reasons = append(reasons, fmt.Sprintf("%s %d", reason, n))
+		}
+		sort.Strings(reasons)
+		sb.WriteString(fmt.Sprintf("未返回: %s\n\n", strings.Join(reasons, ", ")))
+	}
 
 	for _, f := range r.Files {
 		sb.WriteString(fmt.Sprintf("## %s\n\n", f.Path))
 		sb.WriteString("```\n")
 		// 限制内容长度
 		content := f.Content
-		if len(content) > 2000 {
-			content = content[:2000] + "\n... (内容已截断)"
+		if limit > 0 && len(content) > limit {
+			content = content[:limit] + "\n... (内容已截断)"
 		}
 		sb.WriteString(content)
 		sb.WriteString("\n```\n\n")
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mcp-server/internal/outbound"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// shaRe 匹配完整或缩写的提交 SHA
+var shaRe = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// checkout 克隆得到的仓库版本
+type checkout struct {
+	repo   *git.Repository
+	commit *object.Commit
+	ref    string // 解析出的分支或标签名，按 SHA 检出时为空
+}
+
+// RepoInfo 仓库与版本信息，各仓库工具的结果共用
+type RepoInfo struct {
+	RepoURL string `json:"repo_url"`
+	Host    string `json:"host"`
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Ref     string `json:"ref,omitempty"` // 解析出的分支或标签名
+	Commit  string `json:"commit"`        // 实际使用的提交 SHA
+}
+
+// heading 生成 Markdown 标题与版本行
+func (i RepoInfo) heading() string {
+	title := i.Repo
+	if i.Owner != "" {
+		title = i.Owner + "/" + i.Repo
+	}
+	if i.Ref != "" {
+		return fmt.Sprintf("# %s\n\n版本: %s (%s)\n\n", title, i.Ref, i.Commit)
+	}
+	return fmt.Sprintf("# %s\n\n版本: %s\n\n", title, i.Commit)
+}
+
+// repoTarget 检出的仓库版本与要访问的路径
+type repoTarget struct {
+	spec *RepoSpec
+	rm   *remote
+	co   *checkout
+	path string
+}
+
+// openRepo 解析仓库链接并通过克隆缓存浅检出，显式传入的 ref 与 path 优先于链接中的版本与路径
+func openRepo(rawURL, ref, path string) (*repoTarget, error) {
+	return openRepoDepth(rawURL, ref, path, 1)
+}
+
+// openRepoDepth 与 openRepo 相同，depth 为需要的历史提交数，0 表示完整历史
+func openRepoDepth(rawURL, ref, path string, depth int) (*repoTarget, error) {
+	spec, err := ParseRepoURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	rm, err := newRemote(spec.CloneURL)
+	if err != nil {
+		return nil, err
+	}
+
+	linkRef, linkPath := spec.Ref, ""
+	if spec.RefPath != "" {
+		if linkRef, linkPath, err = rm.splitRefPath(spec.RefPath); err != nil {
+			return nil, err
+		}
+	}
+	if r := strings.TrimSpace(ref); r != "" {
+		linkRef = r
+	}
+	if path != "" {
+		linkPath = path
+	}
+
+	co, err := checkoutRepo(rm, linkRef, depth)
+	if err != nil {
+		return nil, err
+	}
+	return &repoTarget{spec: spec, rm: rm, co: co, path: strings.Trim(linkPath, "/")}, nil
+}
+
+// info 返回仓库与版本信息
+func (t *repoTarget) info() RepoInfo {
+	return RepoInfo{
+		RepoURL: t.rm.url,
+		Host:    t.spec.Host,
+		Owner:   t.spec.Owner,
+		Repo:    t.spec.Repo,
+		Ref:     t.co.ref,
+		Commit:  t.co.commit.Hash.String(),
+	}
+}
+
+// checkout 克隆仓库的指定版本，返回的错误中不含凭据
+func (rm *remote) checkout(ref string, depth int) (*checkout, error) {
+	co, err := rm.clone(ref, depth)
+	return co, rm.redact(err)
+}
+
+// clone 克隆仓库的指定版本：ref 为空时浅克隆默认分支，
+// 分支与标签只浅克隆该引用，完整的提交 SHA 只浅获取该提交。
+// depth 为克隆的历史提交数，0 表示完整历史
+func (rm *remote) clone(ref string, depth int) (*checkout, error) {
+	if ref == "" {
+		return rm.cloneRef("", depth)
+	}
+
+	refs, err := rm.list()
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := matchRef(refs, ref); ok {
+		return rm.cloneRef(name, depth)
+	}
+	if shaRe.MatchString(ref) {
+		return rm.fetchCommit(strings.ToLower(ref), depth)
+	}
+	return nil, fmt.Errorf("ref not found: %s", ref)
+}
+
+// list 列出远程仓库的引用
+func (rm *remote) list() ([]*plumbing.Reference, error) {
+	r := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{rm.url},
+	})
+	refs, err := r.List(&git.ListOptions{Auth: rm.auth})
+	if err != nil {
+		return nil, fmt.Errorf("list refs failed: %w", err)
+	}
+	return refs, nil
+}
+
+// matchRef 按完整引用名、分支、标签的顺序匹配 ref
+func matchRef(refs []*plumbing.Reference, ref string) (plumbing.ReferenceName, bool) {
+	candidates := []plumbing.ReferenceName{
+		plumbing.ReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+		plumbing.NewTagReferenceName(ref),
+	}
+	for _, want := range candidates {
+		for _, r := range refs {
+			if r.Name() == want {
+				return want, true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitRefPath 将浏览器链接中的 <ref>/<path> 对照远程引用拆分，分支名可能包含 /，优先匹配最长的分支或标签
+func (rm *remote) splitRefPath(refPath string) (ref, path string, err error) {
+	segs := strings.Split(refPath, "/")
+	if len(segs) == 1 {
+		return refPath, "", nil
+	}
+
+	refs, err := rm.list()
+	if err != nil {
+		return "", "", rm.redact(err)
+	}
+	for i := len(segs); i > 1; i-- {
+		if _, ok := matchRef(refs, strings.Join(segs[:i], "/")); ok {
+			return strings.Join(segs[:i], "/"), strings.Join(segs[i:], "/"), nil
+		}
+	}
+	// 未匹配到时按提交 SHA 或单段分支名处理
+	return segs[0], strings.Join(segs[1:], "/"), nil
+}
+
+// cloneRef 浅克隆单个引用，name 为空时使用远程默认分支
+func (rm *remote) cloneRef(name plumbing.ReferenceName, depth int) (*checkout, error) {
+	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           rm.url,
+		Auth:          rm.auth,
+		ReferenceName: name,
+		SingleBranch:  true,
+		Depth:         depth,
+		Tags:          git.NoTags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("clone failed: %w", err)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD failed: %w", err)
+	}
+	commit, err := peelCommit(r, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = head.Name()
+	}
+	return &checkout{repo: r, commit: commit, ref: name.Short()}, nil
+}
+
+// fetchCommit 只浅获取指定提交，不退回完整克隆：内存中的完整克隆没有大小上限，容易耗尽 Vercel 的内存。
+// 缩写 SHA 需要完整历史才能解析，只有磁盘缓存支持
+func (rm *remote) fetchCommit(sha string, depth int) (*checkout, error) {
+	if len(sha) != 40 {
+		return nil, fmt.Errorf("abbreviated commit SHA %s is not supported, use a full 40-char SHA", sha)
+	}
+
+	r, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("init repository failed: %w", err)
+	}
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{rm.url}}); err != nil {
+		return nil, fmt.Errorf("create remote failed: %w", err)
+	}
+	err = r.Fetch(&git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec(sha + ":refs/heads/checkout")},
+		Depth:    depth,
+		Tags:     git.NoTags,
+		Auth:     rm.auth,
+	})
+	if err != nil {
+		// 出站策略与认证错误原样返回，其余多为服务端不允许获取未公开的提交
+		if isFatalFetchError(err) {
+			return nil, fmt.Errorf("fetch commit failed: %w", err)
+		}
+		return nil, fmt.Errorf("fetch commit %s failed, the server may not allow fetching commits by SHA, use a branch or tag instead: %w", sha, err)
+	}
+
+	commit, err := peelCommit(r, plumbing.NewHash(sha))
+	if err != nil {
+		return nil, err
+	}
+	return &checkout{repo: r, commit: commit}, nil
+}
+
+// isFatalFetchError 出站策略与认证错误，换一种方式拉取也不会成功
+func isFatalFetchError(err error) bool {
+	return errors.Is(err, outbound.ErrBlocked) || errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) || errors.Is(err, transport.ErrRepositoryNotFound)
+}
+
+// peelCommit 取得 hash 指向的提交，附注标签会解引用到其提交
+func peelCommit(r *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if commit, err := r.CommitObject(hash); err == nil {
+		return commit, nil
+	}
+	tag, err := r.TagObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("get commit failed: %w", err)
+	}
+	commit, err := tag.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("get commit failed: %w", err)
+	}
+	return commit, nil
+}
@@ -0,0 +1,553 @@
+package tools
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// RepoCache 克隆缓存，按仓库 URL、ref 与历史深度复用检出结果
+type RepoCache interface {
+	checkout(rm *remote, ref string, depth int) (*checkout, error)
+}
+
+// defaultRepoCache 进程级默认克隆缓存。Vercel 的 /tmp 只有 512MB 且与其他用途共享，
+// 因此默认只使用内存，常驻的 cmd/server 可换成磁盘缓存
+var defaultRepoCache RepoCache = NewMemoryRepoCache(8, 256<<20, 5*time.Minute)
+
+// SetDefaultRepoCache 设置默认克隆缓存，传入 nil 关闭缓存
+func SetDefaultRepoCache(c RepoCache) {
+	defaultRepoCache = c
+}
+
+// checkoutRepo 通过默认克隆缓存检出仓库的指定版本
+func checkoutRepo(rm *remote, ref string, depth int) (*checkout, error) {
+	if defaultRepoCache == nil {
+		return rm.checkout(ref, depth)
+	}
+	co, err := defaultRepoCache.checkout(rm, ref, depth)
+	return co, rm.redact(err)
+}
+
+// repoCacheKey 缓存键，调用方在 URL 中传入的凭据单独隔离
+func repoCacheKey(rm *remote, ref string, depth int) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%d", rm.url, rm.authKey, ref, depth)
+}
+
+// ==================== 内存 LRU ====================
+
+// MemoryRepoCache 按条目数、总字节数与存活时间淘汰的内存克隆缓存
+type MemoryRepoCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	size       int64
+	ll         *list.List
+	items      map[string]*list.Element
+	inflight   map[string]*repoCall
+}
+
+type memoryRepoItem struct {
+	key      string
+	co       *checkout
+	size     int64
+	storedAt time.Time
+}
+
+// repoCall 进行中的克隆，相同键的并发请求等待同一次克隆
+type repoCall struct {
+	done chan struct{}
+	co   *checkout
+	err  error
+}
+
+// NewMemoryRepoCache 创建内存克隆缓存
+func NewMemoryRepoCache(maxEntries int, maxBytes int64, ttl time.Duration) *MemoryRepoCache {
+	return &MemoryRepoCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		inflight:   make(map[string]*repoCall),
+	}
+}
+
+// checkout 命中未过期的缓存时直接返回，否则克隆并写入缓存
+func (m *MemoryRepoCache) checkout(rm *remote, ref string, depth int) (*checkout, error) {
+	key := repoCacheKey(rm, ref, depth)
+
+	m.mu.Lock()
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*memoryRepoItem)
+		if time.Since(item.storedAt) < m.ttl {
+			m.ll.MoveToFront(el)
+			m.mu.Unlock()
+			return item.co, nil
+		}
+		m.remove(el)
+	}
+	if call, ok := m.inflight[key]; ok {
+		m.mu.Unlock()
+		<-call.done
+		return call.co, call.err
+	}
+	call := &repoCall{done: make(chan struct{})}
+	m.inflight[key] = call
+	m.mu.Unlock()
+
+	call.co, call.err = rm.checkout(ref, depth)
+
+	m.mu.Lock()
+	delete(m.inflight, key)
+	if call.err == nil {
+		m.add(key, call.co)
+	}
+	m.mu.Unlock()
+	close(call.done)
+	return call.co, call.err
+}
+
+// add 写入缓存并按容量淘汰，超过总容量的仓库不缓存
+func (m *MemoryRepoCache) add(key string, co *checkout) {
+	size := storageSize(co.repo)
+	if size > m.maxBytes {
+		return
+	}
+	m.items[key] = m.ll.PushFront(&memoryRepoItem{key: key, co: co, size: size, storedAt: time.Now()})
+	m.size += size
+
+	for m.ll.Len() > 0 && (m.ll.Len() > m.maxEntries || m.size > m.maxBytes) {
+		m.remove(m.ll.Back())
+	}
+}
+
+// remove 移除缓存项
+func (m *MemoryRepoCache) remove(el *list.Element) {
+	item := el.Value.(*memoryRepoItem)
+	m.ll.Remove(el)
+	delete(m.items, item.key)
+	m.size -= item.size
+}
+
+// storageSize 统计内存仓库中全部对象的字节数
+func storageSize(r *git.Repository) int64 {
+	st, ok := r.Storer.(*memory.Storage)
+	if !ok {
+		return 0
+	}
+	var size int64
+	for _, obj := range st.ObjectStorage.Objects {
+		size += obj.Size()
+	}
+	return size
+}
+
+// ==================== 磁盘 ====================
+
+// errRepoTooLarge 拉取的数据超过磁盘缓存容量
+var errRepoTooLarge = errors.New("repository exceeds repo cache size limit")
+
+// unshallowDepth 已是浅克隆的仓库需要完整历史时的拉取深度
+const unshallowDepth = 1<<31 - 1
+
+// DiskRepoCache 以裸仓库保存在目录中的克隆缓存，适用于常驻的 cmd/server。
+// 每个仓库只保存一份，之后的请求按需增量拉取用到的分支与标签
+type DiskRepoCache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	locks   map[string]*sync.Mutex
+	size    int64                  // 全部仓库的总字节数
+	repos   map[string]*diskRepo   // 仓库目录 → 大小与最近使用时间
+	fetched map[string]fetchRecord // 仓库目录与 ref → 最近一次拉取
+}
+
+// diskRepo 磁盘上的一个裸仓库
+type diskRepo struct {
+	size int64
+	used time.Time
+}
+
+// fetchRecord 一次拉取的结果，ttl 内直接按本地引用检出
+type fetchRecord struct {
+	at    time.Time
+	local plumbing.ReferenceName // 本地引用，按 SHA 检出时为空
+	ref   string                 // 解析出的分支或标签名
+	depth int                    // 拉取的历史深度，0 表示完整历史
+}
+
+// covers 拉取的历史是否满足 depth
+func (rec fetchRecord) covers(depth int) bool {
+	return rec.depth == 0 || (depth > 0 && depth <= rec.depth)
+}
+
+// NewDiskRepoCache 创建磁盘克隆缓存，ttl 内不重复拉取同一个 ref，总大小超过 maxBytes 时删除最久未用的仓库。
+// 目录中已有的仓库只在创建时统计一次大小
+func NewDiskRepoCache(dir string, maxBytes int64, ttl time.Duration) (*DiskRepoCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DiskRepoCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		locks:    make(map[string]*sync.Mutex),
+		repos:    make(map[string]*diskRepo),
+		fetched:  make(map[string]fetchRecord),
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasSuffix(e.Name(), ".git") {
+			continue
+		}
+		// 进程重启前留下的仓库按修改时间计算
+		p := filepath.Join(dir, e.Name())
+		repo := &diskRepo{size: dirSize(p)}
+		if info, err := e.Info(); err == nil {
+			repo.used = info.ModTime()
+		}
+		d.repos[p] = repo
+		d.size += repo.size
+	}
+	return d, nil
+}
+
+// lock 返回仓库目录的锁，同一仓库的拉取与删除串行进行
+func (d *DiskRepoCache) lock(dir string) *sync.Mutex {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, ok := d.locks[dir]
+	if !ok {
+		l = &sync.Mutex{}
+		d.locks[dir] = l
+	}
+	return l
+}
+
+// checkout 打开或初始化裸仓库，本地已有满足 depth 的历史时直接检出，否则按 depth 增量拉取后检出
+func (d *DiskRepoCache) checkout(rm *remote, ref string, depth int) (*checkout, error) {
+	sum := sha256.Sum256([]byte(rm.url + "\x00" + rm.authKey))
+	dir := filepath.Join(d.dir, hex.EncodeToString(sum[:16])+".git")
+	key := dir + "\x00" + ref
+
+	l := d.lock(dir)
+	l.Lock()
+	defer l.Unlock()
+
+	d.mu.Lock()
+	repo, ok := d.repos[dir]
+	if !ok {
+		repo = &diskRepo{}
+		d.repos[dir] = repo
+	}
+	repo.used = time.Now()
+	d.mu.Unlock()
+
+	r, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		r, err = git.PlainInit(dir, true)
+		if err == nil {
+			_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{rm.url}})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open repository cache failed: %w", err)
+	}
+	shallow, err := r.Storer.Shallow()
+	if err != nil {
+		return nil, fmt.Errorf("open repository cache failed: %w", err)
+	}
+
+	d.mu.Lock()
+	rec, ok := d.fetched[key]
+	d.mu.Unlock()
+	if ok && rec.covers(depth) && time.Since(rec.at) < d.ttl {
+		if co, err := localCheckout(r, rec, ref); err == nil {
+			return co, nil
+		}
+	}
+	// 提交不可变，本地已有且历史足够时无需拉取
+	if shaRe.MatchString(ref) && (depth == 1 || len(shallow) == 0 || ok && rec.covers(depth)) {
+		if co, err := localCheckout(r, fetchRecord{}, ref); err == nil {
+			return co, nil
+		}
+	}
+
+	// 浅克隆的仓库需要完整历史时加深到底
+	fetchDepth := depth
+	if depth == 0 && len(shallow) > 0 {
+		fetchDepth = unshallowDepth
+	}
+	rec, err = d.fetch(rm, r, ref, fetchDepth)
+	d.update(dir)
+	if err != nil {
+		return nil, err
+	}
+	rec.depth = depth
+	co, err := localCheckout(r, rec, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.fetched[key] = rec
+	d.mu.Unlock()
+	d.evict(dir)
+	return co, nil
+}
+
+// fetch 按 depth 增量拉取 ref 对应的远程引用。完整 SHA 直接按 SHA 拉取，
+// 缩写 SHA 或服务端不允许按 SHA 获取时拉取全部分支后在本地解析
+func (d *DiskRepoCache) fetch(rm *remote, r *git.Repository, ref string, depth int) (fetchRecord, error) {
+	refs, err := rm.list()
+	if err != nil {
+		return fetchRecord{}, err
+	}
+
+	rec := fetchRecord{at: time.Now()}
+	name, ok := matchRef(refs, ref)
+	if ref == "" {
+		name, ok = remoteHead(refs)
+		if !ok {
+			return fetchRecord{}, fmt.Errorf("remote HEAD not found")
+		}
+	}
+	switch {
+	case ok && name.IsBranch():
+		rec.local = plumbing.NewRemoteReferenceName("origin", name.Short())
+		rec.ref = name.Short()
+		err = d.fetchSpec(rm, r, fmt.Sprintf("+%s:%s", name, rec.local), depth)
+	case ok:
+		rec.local = name
+		rec.ref = name.Short()
+		err = d.fetchSpec(rm, r, fmt.Sprintf("+%s:%s", name, name), depth)
+	case shaRe.MatchString(ref):
+		if len(ref) == 40 {
+			err = d.fetchSpec(rm, r, fmt.Sprintf("+%s:refs/commits/%s", ref, ref), depth)
+			if err == nil || errors.Is(err, errRepoTooLarge) || isFatalFetchError(err) {
+				break
+			}
+		}
+		// 提交可能离分支顶端很远，需要完整历史，大小由缓存容量限制
+		err = d.fetchSpec(rm, r, "+refs/heads/*:refs/remotes/origin/*", 0)
+	default:
+		return fetchRecord{}, fmt.Errorf("ref not found: %s", ref)
+	}
+	if err != nil {
+		return fetchRecord{}, err
+	}
+	return rec, nil
+}
+
+// fetchSpec 拉取一个 refspec，写入的 packfile 超过仓库可用的缓存容量时中止
+func (d *DiskRepoCache) fetchSpec(rm *remote, r *git.Repository, spec string, depth int) error {
+	st, ok := r.Storer.(*filesystem.Storage)
+	if !ok {
+		return fmt.Errorf("repository cache is not on disk")
+	}
+	dir := st.Filesystem().Root()
+	d.mu.Lock()
+	limit := d.maxBytes - d.repos[dir].size
+	d.mu.Unlock()
+
+	capped, err := git.Open(&cappedStorer{Storage: st, limit: limit}, nil)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	err = capped.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RemoteURL:  rm.url,
+		RefSpecs:   []config.RefSpec{config.RefSpec(spec)},
+		Depth:      depth,
+		Auth:       rm.auth,
+		Tags:       git.NoTags,
+		Force:      true,
+	})
+	if errors.Is(err, errRepoTooLarge) {
+		// 中止的 packfile 不会被清理
+		tmp, _ := filepath.Glob(filepath.Join(dir, "objects", "pack", "tmp_pack_*"))
+		for _, p := range tmp {
+			os.Remove(p)
+		}
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	return nil
+}
+
+// cappedStorer 限制单次拉取写入的 packfile 字节数
+type cappedStorer struct {
+	*filesystem.Storage
+	limit int64
+}
+
+// PackfileWriter 返回超过 limit 即报错的 packfile 写入器
+func (s *cappedStorer) PackfileWriter() (io.WriteCloser, error) {
+	w, err := s.Storage.PackfileWriter()
+	if err != nil {
+		return nil, err
+	}
+	return &cappedWriter{WriteCloser: w, n: s.limit}, nil
+}
+
+// cappedWriter 剩余 n 字节可写
+type cappedWriter struct {
+	io.WriteCloser
+	n int64
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.n {
+		return 0, errRepoTooLarge
+	}
+	w.n -= int64(len(p))
+	return w.WriteCloser.Write(p)
+}
+
+// remoteHead 找到远程 HEAD 指向的分支，服务端未声明 symref 时按哈希匹配
+func remoteHead(refs []*plumbing.Reference) (plumbing.ReferenceName, bool) {
+	var head *plumbing.Reference
+	for _, r := range refs {
+		if r.Name() == plumbing.HEAD {
+			head = r
+		}
+	}
+	if head == nil {
+		return "", false
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		return head.Target(), true
+	}
+	for _, r := range refs {
+		if r.Name().IsBranch() && r.Hash() == head.Hash() {
+			return r.Name(), true
+		}
+	}
+	return "", false
+}
+
+// localCheckout 按本地引用或 SHA 检出
+func localCheckout(r *git.Repository, rec fetchRecord, ref string) (*checkout, error) {
+	var hash plumbing.Hash
+	if rec.local != "" {
+		lr, err := r.Reference(rec.local, true)
+		if err != nil {
+			return nil, fmt.Errorf("ref not found: %s", ref)
+		}
+		hash = lr.Hash()
+	} else {
+		h, err := r.ResolveRevision(plumbing.Revision(ref))
+		if err != nil {
+			return nil, fmt.Errorf("ref not found: %s", ref)
+		}
+		hash = *h
+	}
+	commit, err := peelCommit(r, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &checkout{repo: r, commit: commit, ref: rec.ref}, nil
+}
+
+// update 拉取后重新统计该仓库的大小并更新总大小
+func (d *DiskRepoCache) update(dir string) {
+	size := dirSize(dir)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if repo, ok := d.repos[dir]; ok {
+		d.size += size - repo.size
+		repo.size = size
+	}
+}
+
+// evict 总大小超过上限时按最近使用时间删除仓库。keep 与一分钟内用过的仓库可能仍在读取，不会删除
+func (d *DiskRepoCache) evict(keep string) {
+	type repoDir struct {
+		path string
+		used time.Time
+	}
+	var dirs []repoDir
+	d.mu.Lock()
+	if d.size <= d.maxBytes {
+		d.mu.Unlock()
+		return
+	}
+	for p, repo := range d.repos {
+		dirs = append(dirs, repoDir{path: p, used: repo.used})
+	}
+	d.mu.Unlock()
+
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].used.Before(dirs[j].used) })
+	for _, rd := range dirs {
+		if rd.path == keep || time.Since(rd.used) < time.Minute {
+			continue
+		}
+		// 正在拉取的仓库跳过，避免与其他仓库的淘汰互相等待
+		l := d.lock(rd.path)
+		if !l.TryLock() {
+			continue
+		}
+		d.mu.Lock()
+		if d.size <= d.maxBytes {
+			d.mu.Unlock()
+			l.Unlock()
+			return
+		}
+		d.mu.Unlock()
+
+		err := os.RemoveAll(rd.path)
+		d.mu.Lock()
+		if repo, ok := d.repos[rd.path]; ok && err == nil {
+			d.size -= repo.size
+			delete(d.repos, rd.path)
+		}
+		for k := range d.fetched {
+			if strings.HasPrefix(k, rd.path+"\x00") {
+				delete(d.fetched, k)
+			}
+		}
+		d.mu.Unlock()
+		l.Unlock()
+	}
+}
+
+// dirSize 统计目录下全部文件的字节数
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, e fs.DirEntry, err error) error {
+		if err == nil && !e.IsDir() {
+			if info, err := e.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
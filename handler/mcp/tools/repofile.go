@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// FileResult 仓库文件读取结果
+type FileResult struct {
+	RepoInfo
+	Path     string `json:"path"`
+	SHA      string `json:"sha"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Binary   bool   `json:"binary"`
+	// 文本文件的行范围与内容，行号从 1 开始
+	StartLine  int    `json:"start_line,omitempty"`
+	EndLine    int    `json:"end_line,omitempty"`
+	TotalLines int    `json:"total_lines,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Truncated  bool   `json:"truncated"`         // 是否因字节上限未返回完整的行范围
+	Partial    bool   `json:"partial,omitempty"` // 单行超过字节上限，EndLine 只返回了该行开头
+	Data       []byte `json:"-"`                 // 二进制文件的原始内容
+}
+
+// FileOptions 文件读取选项
+type FileOptions struct {
+	RepoURL   string // 仓库 URL 或 blob 链接
+	Ref       string // 分支、标签或提交 SHA（可选）
+	Path      string // 文件路径
+	StartLine int    // 起始行，默认 1
+	EndLine   int    // 结束行（含），0 表示到文件末尾
+	MaxBytes  int64  // 返回内容的最大字节数，二进制文件超过时报错
+}
+
+// DefaultFileOptions 默认文件读取选项
+func DefaultFileOptions() *FileOptions {
+	return &FileOptions{MaxBytes: 256 << 10}
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *FileOptions) Clamp() *FileOptions {
+	o.StartLine = max(o.StartLine, 1)
+	if o.EndLine < 0 || o.EndLine > 0 && o.EndLine < o.StartLine {
+		o.EndLine = 0
+	}
+	o.MaxBytes = min(max(o.MaxBytes, 1), FileSizeLimit)
+	return o
+}
+
+// ReadFile 读取仓库指定版本的文件；文本文件按行范围返回，二进制文件返回原始内容
+func ReadFile(opts *FileOptions) (*FileResult, error) {
+	target, err := openRepo(opts.RepoURL, opts.Ref, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if target.path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	tree, err := target.co.commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree failed: %w", err)
+	}
+	entry, err := tree.FindEntry(target.path)
+	if err != nil {
+		return nil, fmt.Errorf("path not found: %s", target.path)
+	}
+	switch entry.Mode {
+	case filemode.Dir:
+		return nil, fmt.Errorf("path is a directory: %s", target.path)
+	case filemode.Submodule:
+		return nil, fmt.Errorf("path is a submodule: %s", target.path)
+	}
+	f, err := tree.TreeEntryFile(entry)
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+	f.Name = target.path
+
+	result := &FileResult{
+		RepoInfo: target.info(),
+		Path:     f.Name,
+		SHA:      f.Hash.String(),
+		Size:     f.Size,
+	}
+	if result.Binary, err = f.IsBinary(); err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+
+	r, err := f.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+	defer r.Close()
+
+	if result.Binary {
+		if f.Size > opts.MaxBytes {
+			return nil, fmt.Errorf("binary file too large: %d bytes (max %d)", f.Size, opts.MaxBytes)
+		}
+		if result.Data, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("read file failed: %w", err)
+		}
+		result.MimeType = detectMimeType(f.Name, result.Data)
+		return result, nil
+	}
+
+	result.MimeType = detectMimeType(f.Name, nil)
+	if err := result.readLines(r, opts); err != nil {
+		return nil, fmt.Errorf("read file failed: %w", err)
+	}
+	return result, nil
+}
+
+// readLines 逐行读取并保留行范围内的内容，同时统计总行数
+func (r *FileResult) readLines(rd io.Reader, opts *FileOptions) error {
+	var sb strings.Builder
+	br := bufio.NewReader(rd)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		r.TotalLines = n
+
+		inRange := n >= opts.StartLine && (opts.EndLine == 0 || n <= opts.EndLine)
+		if inRange && !r.Truncated {
+			switch {
+			case int64(sb.Len()+len(line)) <= opts.MaxBytes:
+				r.StartLine = max(r.StartLine, opts.StartLine)
+				r.EndLine = n
+				sb.WriteString(line)
+			case r.StartLine == 0:
+				// 首行即超过上限（如压缩后的代码），截断该行并标记为不完整
+				r.StartLine, r.EndLine = n, n
+				sb.WriteString(strings.ToValidUTF8(line[:opts.MaxBytes], ""))
+				r.Truncated, r.Partial = true, true
+			default:
+				r.Truncated = true
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	r.Content = sb.String()
+	return nil
+}
+
+// detectMimeType 优先按扩展名判断 MIME 类型，二进制文件再按内容嗅探
+func detectMimeType(name string, data []byte) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	if data != nil {
+		return http.DetectContentType(data)
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// URI 文件的资源 URI，由仓库地址、提交与路径组成
+func (r *FileResult) URI() string {
+	return fmt.Sprintf("%s/blob/%s/%s", strings.TrimSuffix(r.RepoURL, ".git"), r.Commit, r.Path)
+}
+
+// ToText 生成带行号的文本
+func (r *FileResult) ToText() string {
+	var sb strings.Builder
+	sb.WriteString(r.heading())
+	switch {
+	case r.TotalLines == 0:
+		sb.WriteString(fmt.Sprintf("%s (空文件)\n", r.Path))
+		return sb.String()
+	case r.StartLine == 0:
+		sb.WriteString(fmt.Sprintf("%s (共 %d 行，请求的行范围超出文件末尾)\n", r.Path, r.TotalLines))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("%s (第 %d-%d 行，共 %d 行)\n\n", r.Path, r.StartLine, r.EndLine, r.TotalLines))
+
+	width := len(strconv.Itoa(r.EndLine))
+	for i, line := range strings.SplitAfter(strings.TrimSuffix(r.Content, "\n"), "\n") {
+		line = strings.TrimRight(line, "\r\n")
+		sb.WriteString(fmt.Sprintf("%*d\t%s\n", width, r.StartLine+i, line))
+	}
+
+	switch {
+	case r.Partial:
+		sb.WriteString(fmt.Sprintf("\n... (第 %d 行超过字节上限，只返回了前 %d 字节；增大 max_bytes 读取整行，或传入 start_line=%d 跳过该行)\n", r.EndLine, len(r.Content), r.EndLine+1))
+	case r.Truncated:
+		sb.WriteString(fmt.Sprintf("\n... (已达到字节上限，传入 start_line=%d 继续读取)\n", r.EndLine+1))
+	}
+	return sb.String()
+}
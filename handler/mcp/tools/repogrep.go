@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// 内容搜索的服务端上限
+const (
+	GrepMatchesLimit = 1000
+	GrepContextLimit = 10
+	grepLineLimit    = 500 // 单行最多返回的字符数，避免压缩文件撑满结果
+)
+
+// GrepMatch 匹配行及其上下文
+type GrepMatch struct {
+	Path   string   `json:"path"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"` // 匹配行之前的上下文
+	After  []string `json:"after,omitempty"`  // 匹配行之后的上下文
+}
+
+// GrepResult 内容搜索结果
+type GrepResult struct {
+	RepoInfo
+	Pattern       string      `json:"pattern"`
+	Path          string      `json:"path,omitempty"`
+	Matches       []GrepMatch `json:"matches"`
+	Count         int         `json:"count"`
+	FilesSearched int         `json:"files_searched"`
+	FilesMatched  int         `json:"files_matched"`
+	Skipped       int         `json:"skipped"`   // 因二进制或超过 1MB 未搜索的文件数
+	Truncated     bool        `json:"truncated"` // 是否因匹配数上限未搜索完
+}
+
+// GrepOptions 内容搜索选项
+type GrepOptions struct {
+	RepoURL    string   // 仓库 URL 或浏览器链接
+	Ref        string   // 分支、标签或提交 SHA（可选）
+	Path       string   // 只搜索该路径下的文件（可选）
+	Pattern    string   // 正则表达式（RE2 语法）或字面量
+	Literal    bool     // 按字面量匹配
+	IgnoreCase bool     // 忽略大小写
+	Include    []string // 包含的 glob，设置后只搜索匹配的文件
+	Exclude    []string // 排除的 glob
+	Context    int      // 匹配行前后的上下文行数
+	MaxMatches int      // 最大匹配行数
+}
+
+// DefaultGrepOptions 默认内容搜索选项
+func DefaultGrepOptions() *GrepOptions {
+	return &GrepOptions{MaxMatches: 100}
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *GrepOptions) Clamp() *GrepOptions {
+	o.Context = min(max(o.Context, 0), GrepContextLimit)
+	o.MaxMatches = min(max(o.MaxMatches, 1), GrepMatchesLimit)
+	return o
+}
+
+// regexp 按选项编译搜索模式
+func (o *GrepOptions) regexp() (*regexp.Regexp, error) {
+	if o.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	expr := o.Pattern
+	if o.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if o.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// Grep 在仓库指定版本的文件内容中搜索
+func Grep(opts *GrepOptions) (*GrepResult, error) {
+	re, err := opts.regexp()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("invalid glob pattern: %q", p)
+		}
+	}
+
+	target, err := openRepo(opts.RepoURL, opts.Ref, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := target.co.commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree failed: %w", err)
+	}
+
+	result := &GrepResult{
+		RepoInfo: target.info(),
+		Pattern:  opts.Pattern,
+		Path:     target.path,
+		Matches:  []GrepMatch{},
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if target.path != "" && !strings.HasPrefix(f.Name, target.path+"/") && f.Name != target.path {
+			return nil
+		}
+		if matchGlobs(opts.Exclude, f.Name) || len(opts.Include) > 0 && !matchGlobs(opts.Include, f.Name) {
+			return nil
+		}
+		if f.Size > FileSizeLimit {
+			result.Skipped++
+			return nil
+		}
+		if bin, err := f.IsBinary(); err != nil || bin {
+			result.Skipped++
+			return nil
+		}
+
+		content, err := readFileContent(f)
+		if err != nil {
+			result.Skipped++
+			return nil
+		}
+		result.FilesSearched++
+		if !result.grepFile(f.Name, content, re, opts) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk tree failed: %w", err)
+	}
+	result.Count = len(result.Matches)
+	return result, nil
+}
+
+// readFileContent 读取文件全部内容
+func readFileContent(f *object.File) (string, error) {
+	r, err := f.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+// grepFile 搜索单个文件，返回 false 表示已达到匹配数上限
+func (r *GrepResult) grepFile(name, content string, re *regexp.Regexp, opts *GrepOptions) bool {
+	if !re.MatchString(content) {
+		return true
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := range lines {
+		lines[i] = clipLine(strings.TrimSuffix(lines[i], "\r"))
+	}
+
+	matched := false
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		if len(r.Matches) >= opts.MaxMatches {
+			r.Truncated = true
+			return false
+		}
+		if !matched {
+			matched = true
+			r.FilesMatched++
+		}
+		m := GrepMatch{Path: name, Line: i + 1, Text: line}
+		if opts.Context > 0 {
+			m.Before = lines[max(i-opts.Context, 0):i]
+			m.After = lines[i+1 : min(i+1+opts.Context, len(lines))]
+		}
+		r.Matches = append(r.Matches, m)
+	}
+	return true
+}
+
+// clipLine 截断过长的行
+func clipLine(line string) string {
+	if len(line) <= grepLineLimit {
+		return line
+	}
+	return strings.ToValidUTF8(line[:grepLineLimit], "") + "…"
+}
+
+// ToText 生成 file:line:text 格式的结果，上下文行用 file-line-text 表示，不连续的片段之间以 -- 分隔
+func (r *GrepResult) ToText() string {
+	var sb strings.Builder
+	sb.WriteString(r.heading())
+	sb.WriteString(fmt.Sprintf("在 %d 个文件中找到 %d 处匹配（%d 个文件）\n\n", r.FilesSearched, r.Count, r.FilesMatched))
+
+	// 上下文行本身也是匹配行时按匹配行输出
+	matchLines := map[string]bool{}
+	for _, m := range r.Matches {
+		matchLines[fmt.Sprintf("%s:%d", m.Path, m.Line)] = true
+	}
+	writeLine := func(p string, n int, text string) {
+		sep := "-"
+		if matchLines[fmt.Sprintf("%s:%d", p, n)] {
+			sep = ":"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s%d%s%s\n", p, sep, n, sep, text))
+	}
+
+	lastPath, lastLine := "", 0
+	for _, m := range r.Matches {
+		start := m.Line - len(m.Before)
+		if m.Path != lastPath {
+			lastLine = 0
+		}
+		if hasContext := len(m.Before)+len(m.After) > 0; hasContext && lastPath != "" && (m.Path != lastPath || start > lastLine+1) {
+			sb.WriteString("--\n")
+		}
+
+		for i, line := range m.Before {
+			if n := start + i; n > lastLine {
+				writeLine(m.Path, n, line)
+			}
+		}
+		if m.Line > lastLine {
+			writeLine(m.Path, m.Line, m.Text)
+		}
+		for i, line := range m.After {
+			if n := m.Line + 1 + i; n > lastLine {
+				writeLine(m.Path, n, line)
+			}
+		}
+		lastPath, lastLine = m.Path, max(lastLine, m.Line+len(m.After))
+	}
+
+	if r.Truncated {
+		sb.WriteString(fmt.Sprintf("\n... (已达到 %d 处匹配上限，可缩小 path 或设置 include)\n", r.Count))
+	}
+	if r.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("\n已跳过 %d 个二进制或超过 1MB 的文件\n", r.Skipped))
+	}
+	return sb.String()
+}
@@ -0,0 +1,393 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+)
+
+// 提交历史的服务端上限
+const (
+	HistoryDepthLimit = 2000
+	LogCommitsLimit   = 500
+)
+
+// CommitInfo 提交信息
+type CommitInfo struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"` // 作者时间
+	Message string    `json:"message"`
+}
+
+// LogResult 提交历史结果
+type LogResult struct {
+	RepoInfo
+	Path      string       `json:"path,omitempty"`
+	Commits   []CommitInfo `json:"commits"`
+	Count     int          `json:"count"`
+	Truncated bool         `json:"truncated"` // 是否因 max_count 未列全
+	Shallow   bool         `json:"shallow"`   // 是否因历史深度未遍历到最早的提交
+}
+
+// LogOptions 提交历史选项
+type LogOptions struct {
+	RepoURL  string // 仓库 URL 或浏览器链接
+	Ref      string // 分支、标签或提交 SHA（可选）
+	Path     string // 只列出修改了该文件或目录的提交（可选）
+	Since    string // 起始时间，YYYY-MM-DD 或 RFC 3339（可选）
+	Until    string // 截止时间，YYYY-MM-DD 表示包含当天（可选）
+	Author   string // 作者名或邮箱包含的文本，忽略大小写（可选）
+	MaxCount int    // 最大提交数
+	Depth    int    // 遍历的历史深度（提交代数）
+}
+
+// DefaultLogOptions 默认提交历史选项
+func DefaultLogOptions() *LogOptions {
+	return &LogOptions{MaxCount: 50, Depth: 200}
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *LogOptions) Clamp() *LogOptions {
+	o.MaxCount = min(max(o.MaxCount, 1), LogCommitsLimit)
+	o.Depth = min(max(o.Depth, 1), HistoryDepthLimit)
+	return o
+}
+
+// Log 列出仓库指定版本的提交历史
+func Log(opts *LogOptions) (*LogResult, error) {
+	since, err := parseDate(opts.Since, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseDate(opts.Until, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid until: %w", err)
+	}
+
+	target, err := openRepoDepth(opts.RepoURL, opts.Ref, opts.Path, opts.Depth)
+	if err != nil {
+		return nil, err
+	}
+	hs := newHistoryStorer(target.co.repo.Storer, target.co.commit.Hash, opts.Depth)
+	r, err := git.Open(hs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open repository failed: %w", err)
+	}
+
+	logOpts := &git.LogOptions{
+		From:  target.co.commit.Hash,
+		Order: git.LogOrderCommitterTime,
+		Since: since,
+		Until: until,
+	}
+	if p := target.path; p != "" {
+		logOpts.PathFilter = func(name string) bool {
+			return name == p || strings.HasPrefix(name, p+"/")
+		}
+	}
+	iter, err := r.Log(logOpts)
+	if err != nil {
+		return nil, fmt.Errorf("get log failed: %w", err)
+	}
+	defer iter.Close()
+
+	result := &LogResult{RepoInfo: target.info(), Path: target.path, Commits: []CommitInfo{}}
+	author := strings.ToLower(strings.TrimSpace(opts.Author))
+	err = iter.ForEach(func(c *object.Commit) error {
+		if author != "" && !strings.Contains(strings.ToLower(c.Author.Name+" <"+c.Author.Email+">"), author) {
+			return nil
+		}
+		if len(result.Commits) >= opts.MaxCount {
+			result.Truncated = true
+			return storer.ErrStop
+		}
+		result.Commits = append(result.Commits, CommitInfo{
+			SHA:     c.Hash.String(),
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Date:    c.Author.When,
+			Message: strings.TrimSpace(c.Message),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk log failed: %w", err)
+	}
+	result.Count = len(result.Commits)
+	result.Shallow = !result.Truncated && hs.truncated()
+	return result, nil
+}
+
+// parseDate 解析 YYYY-MM-DD 或 RFC 3339 时间，endOfDay 时只有日期的输入取当天结束
+func parseDate(s string, endOfDay bool) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, fmt.Errorf("expect YYYY-MM-DD or RFC 3339: %q", s)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// ToText 生成提交列表文本
+func (r *LogResult) ToText() string {
+	var sb strings.Builder
+	sb.WriteString(r.heading())
+	if r.Path != "" {
+		sb.WriteString(fmt.Sprintf("路径: %s\n\n", r.Path))
+	}
+	if r.Count == 0 {
+		sb.WriteString("没有匹配的提交\n")
+	}
+	for _, c := range r.Commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		sb.WriteString(fmt.Sprintf("%s %s %s <%s> %s\n", c.SHA[:12], c.Date.Format(time.DateOnly), c.Author, c.Email, subject))
+	}
+
+	switch {
+	case r.Truncated:
+		sb.WriteString(fmt.Sprintf("\n... (已达到 %d 个提交上限，可设置 max_count、since 或 until)\n", r.Count))
+	case r.Shallow:
+		sb.WriteString("\n... (已达到历史深度，更早的提交可增大 depth 查看)\n")
+	}
+	return sb.String()
+}
+
+// BlameLine 逐行作者信息
+type BlameLine struct {
+	Line     int       `json:"line"`
+	SHA      string    `json:"sha"`
+	Author   string    `json:"author"`
+	Email    string    `json:"email"`
+	Date     time.Time `json:"date"`
+	Text     string    `json:"text"`
+	Boundary bool      `json:"boundary,omitempty"` // 提交位于历史深度边界，该行可能来自更早的提交
+}
+
+// BlameResult 逐行作者信息结果
+type BlameResult struct {
+	RepoInfo
+	Path       string      `json:"path"`
+	Lines      []BlameLine `json:"lines"`
+	StartLine  int         `json:"start_line,omitempty"`
+	EndLine    int         `json:"end_line,omitempty"`
+	TotalLines int         `json:"total_lines"`
+}
+
+// BlameOptions 逐行作者信息选项
+type BlameOptions struct {
+	RepoURL   string // 仓库 URL 或 blob 链接
+	Ref       string // 分支、标签或提交 SHA（可选）
+	Path      string // 文件路径
+	StartLine int    // 起始行，默认 1
+	EndLine   int    // 结束行（含），0 表示到文件末尾
+	Depth     int    // 遍历的历史深度（提交代数）
+}
+
+// DefaultBlameOptions 默认逐行作者信息选项
+func DefaultBlameOptions() *BlameOptions {
+	return &BlameOptions{Depth: 200}
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *BlameOptions) Clamp() *BlameOptions {
+	o.StartLine = max(o.StartLine, 1)
+	if o.EndLine < 0 || o.EndLine > 0 && o.EndLine < o.StartLine {
+		o.EndLine = 0
+	}
+	o.Depth = min(max(o.Depth, 1), HistoryDepthLimit)
+	return o
+}
+
+// Blame 计算文件每一行最后修改的提交，超出历史深度的行归于边界提交
+func Blame(opts *BlameOptions) (*BlameResult, error) {
+	target, err := openRepoDepth(opts.RepoURL, opts.Ref, opts.Path, opts.Depth)
+	if err != nil {
+		return nil, err
+	}
+	if target.path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	f, err := target.co.commit.File(target.path)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", target.path)
+	}
+	if f.Size > FileSizeLimit {
+		return nil, fmt.Errorf("file too large: %d bytes (max %d)", f.Size, FileSizeLimit)
+	}
+	if bin, err := f.IsBinary(); err != nil || bin {
+		return nil, fmt.Errorf("binary file: %s", target.path)
+	}
+
+	hs := newHistoryStorer(target.co.repo.Storer, target.co.commit.Hash, opts.Depth)
+	commit, err := object.GetCommit(hs, target.co.commit.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("get commit failed: %w", err)
+	}
+	blame, err := git.Blame(commit, target.path)
+	if err != nil {
+		return nil, fmt.Errorf("blame failed: %w", err)
+	}
+
+	result := &BlameResult{
+		RepoInfo:   target.info(),
+		Path:       target.path,
+		Lines:      []BlameLine{},
+		TotalLines: len(blame.Lines),
+	}
+	for i, l := range blame.Lines {
+		n := i + 1
+		if n < opts.StartLine || opts.EndLine > 0 && n > opts.EndLine {
+			continue
+		}
+		if result.StartLine == 0 {
+			result.StartLine = n
+		}
+		result.EndLine = n
+		result.Lines = append(result.Lines, BlameLine{
+			Line:     n,
+			SHA:      l.Hash.String(),
+			Author:   l.AuthorName,
+			Email:    l.Author,
+			Date:     l.Date,
+			Text:     l.Text,
+			Boundary: hs.isBoundary(l.Hash),
+		})
+	}
+	return result, nil
+}
+
+// ToText 生成与 git blame 相似的文本，边界提交以 ^ 标记
+func (r *BlameResult) ToText() string {
+	var sb strings.Builder
+	sb.WriteString(r.heading())
+	if len(r.Lines) == 0 {
+		sb.WriteString(fmt.Sprintf("%s (共 %d 行，请求的行范围内没有内容)\n", r.Path, r.TotalLines))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("%s (第 %d-%d 行，共 %d 行)\n\n", r.Path, r.StartLine, r.EndLine, r.TotalLines))
+
+	width := 0
+	for _, l := range r.Lines {
+		width = max(width, len([]rune(l.Author)))
+	}
+	boundary := false
+	for _, l := range r.Lines {
+		mark := " "
+		if l.Boundary {
+			mark, boundary = "^", true
+		}
+		sb.WriteString(fmt.Sprintf("%s%s (%-*s %s %*d) %s\n",
+			mark, l.SHA[:8], width, l.Author, l.Date.Format(time.DateOnly), len(fmt.Sprint(r.EndLine)), l.Line, l.Text))
+	}
+
+	if boundary {
+		sb.WriteString("\n^ 标记的提交位于历史深度边界，这些行可能来自更早的提交，可增大 depth 查看\n")
+	}
+	return sb.String()
+}
+
+// historyStorer 把历史限制在起始提交之后 depth 代以内：父提交超出范围或在浅克隆中缺失的提交视为根提交，
+// 与 git 在浅克隆中的处理一致，使 go-git 的 log 与 blame 在边界处停止而不是报错
+type historyStorer struct {
+	storage.Storer
+	boundary map[plumbing.Hash][]plumbing.Hash // 边界提交 → 保留的父提交
+}
+
+// newHistoryStorer 从 from 按代广度优先遍历 depth 代，记录边界提交
+func newHistoryStorer(s storage.Storer, from plumbing.Hash, depth int) *historyStorer {
+	hs := &historyStorer{Storer: s, boundary: make(map[plumbing.Hash][]plumbing.Hash)}
+
+	within := make(map[plumbing.Hash]bool)
+	var commits []*object.Commit
+	queue := []plumbing.Hash{from}
+	for gen := 0; gen < depth && len(queue) > 0; gen++ {
+		var next []plumbing.Hash
+		for _, h := range queue {
+			if within[h] {
+				continue
+			}
+			c, err := object.GetCommit(s, h)
+			if err != nil {
+				continue
+			}
+			within[h] = true
+			commits = append(commits, c)
+			next = append(next, c.ParentHashes...)
+		}
+		queue = next
+	}
+
+	for _, c := range commits {
+		var kept []plumbing.Hash
+		for _, p := range c.ParentHashes {
+			if within[p] {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) < len(c.ParentHashes) {
+			hs.boundary[c.Hash] = kept
+		}
+	}
+	return hs
+}
+
+// truncated 判断历史是否在边界处被截断
+func (s *historyStorer) truncated() bool {
+	return len(s.boundary) > 0
+}
+
+// isBoundary 判断提交是否为边界提交
+func (s *historyStorer) isBoundary(h plumbing.Hash) bool {
+	_, ok := s.boundary[h]
+	return ok
+}
+
+// EncodedObject 读取对象，边界提交去掉范围外的父提交后重新编码
+func (s *historyStorer) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	obj, err := s.Storer.EncodedObject(t, h)
+	if err != nil || obj.Type() != plumbing.CommitObject {
+		return obj, err
+	}
+	parents, ok := s.boundary[h]
+	if !ok {
+		return obj, nil
+	}
+
+	c, err := object.DecodeCommit(s.Storer, obj)
+	if err != nil {
+		return nil, err
+	}
+	c.ParentHashes = parents
+	rewritten := &plumbing.MemoryObject{}
+	if err := c.Encode(rewritten); err != nil {
+		return nil, err
+	}
+	return &boundaryObject{EncodedObject: rewritten, hash: h}, nil
+}
+
+// boundaryObject 重新编码的边界提交，保留原始哈希
+type boundaryObject struct {
+	plumbing.EncodedObject
+	hash plumbing.Hash
+}
+
+func (o *boundaryObject) Hash() plumbing.Hash { return o.hash }
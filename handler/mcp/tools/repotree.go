@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// 目录树的服务端上限
+const (
+	TreeEntriesLimit = 5000
+)
+
+// 目录树条目类型
+const (
+	EntryFile      = "file"
+	EntryDir       = "dir"
+	EntrySymlink   = "symlink"
+	EntrySubmodule = "submodule"
+)
+
+// TreeEntry 目录树条目
+type TreeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size,omitempty"` // 文件字节数，目录与子模块为 0
+	SHA  string `json:"sha"`            // blob 或 tree 的 SHA，子模块为其提交
+}
+
+// TreeResult 目录树结果
+type TreeResult struct {
+	RepoInfo
+	Path      string      `json:"path,omitempty"`
+	Entries   []TreeEntry `json:"entries"`
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated"` // 是否因条目数上限未列全
+}
+
+// TreeOptions 目录树选项
+type TreeOptions struct {
+	RepoURL    string   // 仓库 URL 或浏览器链接
+	Ref        string   // 分支、标签或提交 SHA（可选）
+	Path       string   // 只列出该目录下的条目（可选）
+	MaxDepth   int      // 相对 Path 的最大深度，0 表示不限
+	Include    []string // 包含的 glob，设置后只列出匹配的条目
+	Exclude    []string // 排除的 glob，匹配的目录不再展开
+	MaxEntries int      // 最大条目数
+}
+
+// DefaultTreeOptions 默认目录树选项
+func DefaultTreeOptions() *TreeOptions {
+	return &TreeOptions{MaxEntries: 1000}
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *TreeOptions) Clamp() *TreeOptions {
+	o.MaxDepth = max(o.MaxDepth, 0)
+	o.MaxEntries = min(max(o.MaxEntries, 1), TreeEntriesLimit)
+	return o
+}
+
+// ListTree 列出仓库指定版本的目录树
+func ListTree(opts *TreeOptions) (*TreeResult, error) {
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("invalid glob pattern: %q", p)
+		}
+	}
+
+	target, err := openRepo(opts.RepoURL, opts.Ref, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	result := &TreeResult{RepoInfo: target.info(), Path: target.path, Entries: []TreeEntry{}}
+
+	root, err := target.co.commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree failed: %w", err)
+	}
+
+	tree := root
+	if target.path != "" {
+		if tree, err = root.Tree(target.path); err != nil {
+			// 路径指向文件时只返回该文件
+			entry, ferr := root.FindEntry(target.path)
+			if ferr != nil {
+				return nil, fmt.Errorf("path not found: %s", target.path)
+			}
+			result.Entries = append(result.Entries, treeEntry(target, target.path, entry))
+			result.Count = 1
+			return result, nil
+		}
+	}
+
+	w := &treeWalker{target: target, opts: opts, result: result}
+	w.walk(tree, target.path, 1)
+	result.Count = len(result.Entries)
+	return result, nil
+}
+
+// treeWalker 深度优先遍历目录树；设置 include 时，匹配条目的上级目录即使不匹配也会列出
+type treeWalker struct {
+	target  *repoTarget
+	opts    *TreeOptions
+	result  *TreeResult
+	pending []TreeEntry // 尚未列出的上级目录
+}
+
+// walk 遍历 tree，返回 false 表示已达到条目上限
+func (w *treeWalker) walk(tree *object.Tree, prefix string, depth int) bool {
+	for _, e := range tree.Entries {
+		p := path.Join(prefix, e.Name)
+		if matchGlobs(w.opts.Exclude, p) {
+			continue
+		}
+		entry := treeEntry(w.target, p, &e)
+		matched := len(w.opts.Include) == 0 || matchGlobs(w.opts.Include, p)
+		if matched && !w.emit(entry) {
+			return false
+		}
+
+		if e.Mode != filemode.Dir || (w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth) {
+			continue
+		}
+		sub, err := tree.Tree(e.Name)
+		if err != nil {
+			continue
+		}
+		if !matched {
+			w.pending = append(w.pending, entry)
+		}
+		ok := w.walk(sub, p, depth+1)
+		if n := len(w.pending); !matched && n > 0 && w.pending[n-1].Path == p {
+			w.pending = w.pending[:n-1]
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// emit 先列出待定的上级目录，再列出条目
+func (w *treeWalker) emit(entry TreeEntry) bool {
+	if len(w.result.Entries)+len(w.pending)+1 > w.opts.MaxEntries {
+		w.result.Truncated = true
+		return false
+	}
+	w.result.Entries = append(w.result.Entries, w.pending...)
+	w.result.Entries = append(w.result.Entries, entry)
+	w.pending = w.pending[:0]
+	return true
+}
+
+// treeEntry 转换 go-git 的条目，文件大小从对象存储中读取
+func treeEntry(target *repoTarget, p string, e *object.TreeEntry) TreeEntry {
+	entry := TreeEntry{Path: p, SHA: e.Hash.String()}
+	switch e.Mode {
+	case filemode.Dir:
+		entry.Type = EntryDir
+	case filemode.Submodule:
+		entry.Type = EntrySubmodule
+	case filemode.Symlink:
+		entry.Type = EntrySymlink
+	default:
+		entry.Type = EntryFile
+	}
+	if entry.Type == EntryFile || entry.Type == EntrySymlink {
+		if size, err := target.co.repo.Storer.EncodedObjectSize(e.Hash); err == nil {
+			entry.Size = size
+		}
+	}
+	return entry
+}
+
+// ToText 生成缩进的文本目录树
+func (r *TreeResult) ToText() string {
+	var sb strings.Builder
+	sb.WriteString(r.heading())
+
+	// path 指向文件时从其所在目录开始显示
+	dir := r.Path
+	if len(r.Entries) == 1 && r.Entries[0].Path == r.Path {
+		if dir = path.Dir(r.Path); dir == "." {
+			dir = ""
+		}
+	}
+	base := 0
+	if dir != "" {
+		sb.WriteString(dir + "/\n")
+		base = strings.Count(dir, "/") + 1
+	}
+	for _, e := range r.Entries {
+		indent := strings.Repeat("  ", strings.Count(e.Path, "/")-base)
+		if dir != "" {
+			indent = "  " + indent
+		}
+		switch e.Type {
+		case EntryDir:
+			sb.WriteString(fmt.Sprintf("%s%s/\n", indent, path.Base(e.Path)))
+		case EntrySubmodule:
+			sb.WriteString(fmt.Sprintf("%s%s @ %s\n", indent, path.Base(e.Path), e.SHA[:12]))
+		default:
+			sb.WriteString(fmt.Sprintf("%s%s  (%s)\n", indent, path.Base(e.Path), formatSize(e.Size)))
+		}
+	}
+
+	if r.Truncated {
+		sb.WriteString(fmt.Sprintf("\n... (已达到 %d 个条目上限，可缩小 path 或设置 max_depth)\n", r.Count))
+	}
+	return sb.String()
+}
+
+// formatSize 格式化字节数
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scpRe 匹配 scp 形式的 SSH 地址，如 git@github.com:owner/repo.git
+var scpRe = regexp.MustCompile(`^(?:([^@/]+)@)?([^:/]+):([^/].*)$`)
+
+// forgeHosts 仓库路径固定为 owner/repo 两级的托管平台
+var forgeHosts = map[string]bool{
+	"github.com":    true,
+	"gitea.com":     true,
+	"codeberg.org":  true,
+	"bitbucket.org": true,
+}
+
+// viewSegments 浏览器链接中紧跟 owner/repo 的路径段
+var viewSegments = map[string]bool{
+	"tree": true, "blob": true, "raw": true, "src": true,
+	"commit": true, "commits": true, "releases": true,
+}
+
+// RepoSpec 从仓库链接中解析出的信息
+type RepoSpec struct {
+	Host     string // 主机名
+	Owner    string // 所有者，GitLab 子组为 group/subgroup
+	Repo     string // 仓库名
+	CloneURL string // 克隆地址
+	// RefPath 浏览器链接中的 <ref>/<path>，分支名可能包含 /，需要对照远程引用拆分
+	RefPath string
+	// Ref 链接直接指向的提交或发布标签，与 RefPath 互斥
+	Ref string
+}
+
+// ParseRepoURL 解析仓库链接，支持 GitHub、GitLab（含子组）、Gitea/Codeberg、Bitbucket 与普通 Git 地址，
+// 以及 /tree/<ref>/<path>、/-/blob/<ref>/<path>、/src/branch/<ref>/<path> 等浏览器链接
+func ParseRepoURL(raw string) (*RepoSpec, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("repository URL is required")
+	}
+
+	// scp 形式的 SSH 地址不含浏览器路径
+	if !strings.Contains(raw, "://") {
+		m := scpRe.FindStringSubmatch(raw)
+		if m == nil {
+			return nil, fmt.Errorf("invalid repository URL: %s", redactURL(raw))
+		}
+		spec := &RepoSpec{Host: strings.ToLower(m[2]), CloneURL: raw}
+		spec.Owner, spec.Repo = splitRepoPath(splitSegments(m[3]))
+		return spec, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid repository URL: %s", redactURL(raw))
+	}
+	spec := &RepoSpec{Host: strings.ToLower(u.Hostname())}
+	segs := splitSegments(u.Path)
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		spec.CloneURL = raw
+		spec.Owner, spec.Repo = splitRepoPath(segs)
+		return spec, nil
+	}
+
+	// raw.githubusercontent.com/<owner>/<repo>/<ref>/<path>
+	if spec.Host == "raw.githubusercontent.com" {
+		if len(segs) < 2 {
+			return nil, fmt.Errorf("invalid repository URL: %s", redactURL(raw))
+		}
+		spec.Host = "github.com"
+		u.Host = "github.com"
+		spec.RefPath = strings.Join(segs[2:], "/")
+		segs = segs[:2]
+	}
+
+	repoSegs, view := splitView(spec.Host, segs)
+	if len(repoSegs) < 1 {
+		return nil, fmt.Errorf("invalid repository URL: %s", redactURL(raw))
+	}
+	if view != nil {
+		spec.RefPath, spec.Ref = parseView(view)
+	}
+	spec.Owner, spec.Repo = splitRepoPath(repoSegs)
+
+	// 托管平台统一使用 .git 结尾的克隆地址，其他服务器保留原路径
+	repoPath := strings.Join(repoSegs, "/")
+	if (forgeHosts[spec.Host] || spec.Host == "gitlab.com" || view != nil) && !strings.HasSuffix(repoPath, ".git") {
+		repoPath += ".git"
+	}
+	clone := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: "/" + repoPath}
+	spec.CloneURL = clone.String()
+	return spec, nil
+}
+
+// splitSegments 拆分路径段，去掉空段
+func splitSegments(p string) []string {
+	var segs []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
+
+// splitView 区分仓库路径与浏览器视图路径
+func splitView(host string, segs []string) (repo, view []string) {
+	// GitLab 用 /-/ 分隔仓库路径与视图，仓库路径可包含多级子组
+	for i, s := range segs {
+		if s == "-" {
+			return segs[:i], segs[i+1:]
+		}
+	}
+	if len(segs) >= 2 && (forgeHosts[host] || len(segs) > 2 && viewSegments[segs[2]]) {
+		if len(segs) > 2 {
+			return segs[:2], segs[2:]
+		}
+		return segs[:2], nil
+	}
+	// 其他情况整条路径都是仓库，如 GitLab 子组或自建 Git 服务
+	return segs, nil
+}
+
+// parseView 解析视图路径中的 ref 与文件路径
+func parseView(view []string) (refPath, ref string) {
+	if len(view) < 2 {
+		return "", ""
+	}
+	switch view[0] {
+	case "tree", "blob", "raw", "src":
+		rest := view[1:]
+		// Gitea / Codeberg：/src/branch/<ref>、/src/tag/<ref>、/src/commit/<sha>
+		if len(rest) >= 2 && (rest[0] == "branch" || rest[0] == "tag" || rest[0] == "commit") {
+			return strings.Join(rest[1:], "/"), ""
+		}
+		return strings.Join(rest, "/"), ""
+	case "commit", "commits":
+		return "", view[1]
+	case "releases":
+		// GitHub：/releases/tag/<tag>
+		if len(view) >= 3 && view[1] == "tag" {
+			return "", strings.Join(view[2:], "/")
+		}
+	}
+	return "", ""
+}
+
+// splitRepoPath 将仓库路径拆为所有者与仓库名
+func splitRepoPath(segs []string) (owner, repo string) {
+	if len(segs) == 0 {
+		return "", ""
+	}
+	repo = strings.TrimSuffix(segs[len(segs)-1], ".git")
+	owner = strings.Join(segs[:len(segs)-1], "/")
+	return owner, repo
+}
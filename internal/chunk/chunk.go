@@ -0,0 +1,294 @@
+package chunk
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 分块大小的服务端下限与上限
+const (
+	MinChars      = 500
+	MaxCharsLimit = 200000
+)
+
+// Options 分块选项，两者都为 0 时不分块
+type Options struct {
+	MaxChars  int // 每块最多字符数
+	MaxTokens int // 每块最多 token 数（估算）
+}
+
+// Clamp 按服务端上下限裁剪选项
+func (o Options) Clamp() Options {
+	if o.MaxChars > 0 {
+		o.MaxChars = min(max(o.MaxChars, MinChars), MaxCharsLimit)
+	}
+	if o.MaxTokens > 0 {
+		o.MaxTokens = min(max(o.MaxTokens, MinChars/4), MaxCharsLimit/4)
+	}
+	return o
+}
+
+// Enabled 是否需要分块
+func (o Options) Enabled() bool {
+	return o.MaxChars > 0 || o.MaxTokens > 0
+}
+
+// Chunk 一页分块结果
+type Chunk struct {
+	Page  int    `json:"page"`           // 当前页，从 1 开始
+	Pages int    `json:"pages"`          // 总页数
+	Next  int    `json:"next,omitempty"` // 续读游标，即下一页页码，最后一页为 0
+	Text  string `json:"text"`
+}
+
+// Markdown 返回带分页提示的 Markdown
+func (c *Chunk) Markdown() string {
+	if c.Pages <= 1 {
+		return c.Text
+	}
+	footer := fmt.Sprintf("\n\n---\n第 %d/%d 段", c.Page, c.Pages)
+	if c.Next > 0 {
+		footer += fmt.Sprintf("，传入 page=%d 继续读取", c.Next)
+	}
+	return strings.TrimRight(c.Text, "\n") + footer + "\n"
+}
+
+// EstimateTokens 粗略估算 token 数：ASCII 约 4 字符一个 token，其余字符各算一个
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// Page 按选项分块并返回第 page 页
+func Page(md string, opts Options, page int) (*Chunk, error) {
+	chunks := Split(md, opts)
+	if page <= 0 {
+		page = 1
+	}
+	if page > len(chunks) {
+		return nil, fmt.Errorf("page %d out of range: %d pages", page, len(chunks))
+	}
+
+	c := &Chunk{Page: page, Pages: len(chunks), Text: chunks[page-1]}
+	if page < len(chunks) {
+		c.Next = page + 1
+	}
+	return c, nil
+}
+
+// Split 在标题与段落边界切分 Markdown，超长段落再按句子切分，代码块跨块时补全围栏
+func Split(md string, opts Options) []string {
+	if !opts.Enabled() || opts.fits(md) {
+		return []string{md}
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+	}
+
+	for _, b := range splitBlocks(md) {
+		// 当前块已过半时让标题开启新块，避免标题与正文分离
+		if isHeading(b) && cur.Len() > 0 && opts.half(cur.String()) {
+			flush()
+		}
+
+		candidate := b
+		if cur.Len() > 0 {
+			candidate = cur.String() + "\n\n" + b
+		}
+		if opts.fits(candidate) {
+			cur.Reset()
+			cur.WriteString(candidate)
+			continue
+		}
+
+		flush()
+		if opts.fits(b) {
+			cur.WriteString(b)
+			continue
+		}
+		for _, piece := range opts.splitBlock(b) {
+			cur.WriteString(piece)
+			flush()
+		}
+	}
+	flush()
+
+	if len(chunks) == 0 {
+		return []string{""}
+	}
+	return chunks
+}
+
+// fits 判断文本是否在分块上限内
+func (o Options) fits(s string) bool {
+	if o.MaxChars > 0 && utf8.RuneCountInString(s) > o.MaxChars {
+		return false
+	}
+	if o.MaxTokens > 0 && EstimateTokens(s) > o.MaxTokens {
+		return false
+	}
+	return true
+}
+
+// half 判断文本是否已超过上限的一半
+func (o Options) half(s string) bool {
+	if o.MaxChars > 0 && utf8.RuneCountInString(s)*2 > o.MaxChars {
+		return true
+	}
+	return o.MaxTokens > 0 && EstimateTokens(s)*2 > o.MaxTokens
+}
+
+// splitBlocks 以空行切分段落，围栏代码块整体作为一个段落
+func splitBlocks(md string) []string {
+	var blocks []string
+	var cur []string
+	inFence := false
+	flush := func() {
+		if len(cur) > 0 {
+			blocks = append(blocks, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		if isFence(line) {
+			if !inFence {
+				flush()
+			}
+			cur = append(cur, line)
+			if inFence {
+				flush()
+			}
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if !inFence && strings.HasPrefix(line, "#") {
+			flush()
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return blocks
+}
+
+// splitBlock 切分单个超长段落：代码块按行切分并补全围栏，普通段落按句子切分
+func (o Options) splitBlock(b string) []string {
+	lines := strings.Split(b, "\n")
+	if isFence(lines[0]) {
+		open, body := lines[0], lines[1:]
+		if len(body) > 0 && isFence(body[len(body)-1]) {
+			body = body[:len(body)-1]
+		}
+		// 围栏行本身放不进一块或代码块没有内容时按普通文本切分，保留围栏行
+		if len(body) > 0 && o.fits(open+"\n\n```") {
+			return o.pack(body, "\n", open+"\n", "\n```")
+		}
+	}
+	return o.pack(splitSentences(b), "", "", "")
+}
+
+// pack 将片段贪心装入分块，单个片段仍超长时按字符硬切
+func (o Options) pack(parts []string, sep, prefix, suffix string) []string {
+	var out []string
+	cur := ""
+	for _, p := range parts {
+		candidate := p
+		if cur != "" {
+			candidate = cur + sep + p
+		}
+		if o.fits(prefix + candidate + suffix) {
+			cur = candidate
+			continue
+		}
+		if cur != "" {
+			out = append(out, prefix+cur+suffix)
+		}
+		cur = p
+		for cur != "" && !o.fits(prefix+cur+suffix) {
+			head, rest := o.hardCut(cur, prefix, suffix)
+			out = append(out, prefix+head+suffix)
+			cur = rest
+		}
+	}
+	if cur != "" {
+		out = append(out, prefix+cur+suffix)
+	}
+	return out
+}
+
+// hardCut 按字符切下能装入一块的最长前缀，至少切下一个字符以保证前进
+func (o Options) hardCut(s, prefix, suffix string) (string, string) {
+	if s == "" {
+		return "", ""
+	}
+	runes := []rune(s)
+	lo, hi := 1, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if o.fits(prefix + string(runes[:mid]) + suffix) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo]), string(runes[lo:])
+}
+
+// splitSentences 在句末标点后切分，保留标点与其后的空白
+func splitSentences(s string) []string {
+	var out []string
+	start := 0
+	runes := []rune(s)
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?。！？\n", r) {
+			continue
+		}
+		// 英文句点后须跟空白，避免切开小数与缩写
+		if r == '.' || r == '!' || r == '?' {
+			if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+				continue
+			}
+		}
+		end := i + 1
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		if end > start {
+			out = append(out, string(runes[start:end]))
+			start = end
+		}
+	}
+	if start < len(runes) {
+		out = append(out, string(runes[start:]))
+	}
+	return out
+}
+
+// isHeading 判断段落是否以 Markdown 标题开头
+func isHeading(b string) bool {
+	return strings.HasPrefix(b, "#")
+}
+
+// isFence 判断是否为代码块围栏行
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
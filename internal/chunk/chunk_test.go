@@ -0,0 +1,35 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitLongFenceLine(t *testing.T) {
+	opts := Options{MaxChars: 500}
+	cases := map[string]string{
+		"long fence line": "intro\n\n```" + strings.Repeat("x", 700) + "\nfmt.Println(1)\n```\n",
+		"fence only":      "intro\n\n```" + strings.Repeat("y", 700) + "\n",
+	}
+	for name, md := range cases {
+		t.Run(name, func(t *testing.T) {
+			chunks := Split(md, opts)
+			joined := strings.Join(chunks, "")
+			for _, c := range chunks {
+				if n := utf8.RuneCountInString(c); n > opts.MaxChars {
+					t.Errorf("chunk has %d chars, want <= %d", n, opts.MaxChars)
+				}
+			}
+			// 围栏行的内容不能丢失
+			for _, r := range "xy" {
+				if want, got := strings.Count(md, string(r)), strings.Count(joined, string(r)); got != want {
+					t.Errorf("%q count = %d, want %d", r, got, want)
+				}
+			}
+			if strings.Contains(md, "fmt.Println(1)") && !strings.Contains(joined, "fmt.Println(1)") {
+				t.Error("code body dropped")
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// defaultUserAgent 两条抓取路径共用的浏览器 UA
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36"
+
+// ErrCallAuthDisabled 服务端未允许工具调用传入请求头、Cookie 或凭据
+var ErrCallAuthDisabled = errors.New("per-call headers, cookies and credentials are disabled")
+
+// forbiddenHeaders 不允许通过配置或参数覆盖的请求头
+var forbiddenHeaders = []string{
+	"Host", "Content-Length", "Connection", "Transfer-Encoding", "Upgrade", "Te", "Trailer",
+	"Proxy-Authorization", "Proxy-Connection",
+}
+
+// HostAuth 发往某个主机的请求头、Cookie 与 Basic 认证
+type HostAuth struct {
+	Headers  map[string]string `json:"headers,omitempty"`
+	Cookies  map[string]string `json:"cookies,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+}
+
+// AuthConfig 按主机匹配的请求配置，键为主机名、*.example.com 或 *
+type AuthConfig map[string]*HostAuth
+
+// defaultAuth 进程级默认请求配置
+var defaultAuth AuthConfig
+
+// SetDefaultAuth 设置默认请求配置
+func SetDefaultAuth(cfg AuthConfig) {
+	defaultAuth = cfg
+}
+
+// AuthConfigFromEnv 读取 SCRAPER_CONFIG，可以是 JSON 文本或 JSON 文件路径
+func AuthConfigFromEnv() (AuthConfig, error) {
+	raw := strings.TrimSpace(os.Getenv("SCRAPER_CONFIG"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	data := []byte(raw)
+	if !strings.HasPrefix(raw, "{") {
+		var err error
+		if data, err = os.ReadFile(raw); err != nil {
+			return nil, fmt.Errorf("read scraper config failed: %w", err)
+		}
+	}
+
+	var cfg AuthConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse scraper config failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// CallAuthAllowed 是否允许工具调用传入请求头、Cookie 与凭据，由 SCRAPER_ALLOW_CALL_AUTH=true 开启
+func CallAuthAllowed() bool {
+	return os.Getenv("SCRAPER_ALLOW_CALL_AUTH") == "true"
+}
+
+// ParseCallAuth 解析工具参数：headers 为 "Name: value"，cookies 为 "name=value"，basicAuth 为 "user:password"
+func ParseCallAuth(headers, cookies []string, basicAuth string) (*HostAuth, error) {
+	if len(headers) == 0 && len(cookies) == 0 && basicAuth == "" {
+		return nil, nil
+	}
+	if !CallAuthAllowed() {
+		return nil, ErrCallAuthDisabled
+	}
+
+	auth := &HostAuth{Headers: map[string]string{}, Cookies: map[string]string{}}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header: %q", h)
+		}
+		auth.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	for _, c := range cookies {
+		name, value, ok := strings.Cut(c, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid cookie: %q", c)
+		}
+		auth.Cookies[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if basicAuth != "" {
+		user, pass, ok := strings.Cut(basicAuth, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid basic auth: expected user:password")
+		}
+		auth.Username, auth.Password = user, pass
+	}
+	return auth, nil
+}
+
+// With 返回为 rawURL 的主机追加 auth 的副本，同名项覆盖服务端配置
+func (c AuthConfig) With(rawURL string, auth *HostAuth) AuthConfig {
+	if auth == nil {
+		return c
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return c
+	}
+
+	host := strings.ToLower(u.Hostname())
+	out := make(AuthConfig, len(c)+1)
+	for k, v := range c {
+		out[k] = v
+	}
+	out[host] = out.lookup(host).merge(auth)
+	return out
+}
+
+// lookup 按 *、*.example.com、精确主机的顺序合并匹配的配置
+func (c AuthConfig) lookup(host string) *HostAuth {
+	var out *HostAuth
+	if a, ok := c["*"]; ok {
+		out = out.merge(a)
+	}
+	// 通配符从最宽到最窄
+	labels := strings.Split(host, ".")
+	for i := len(labels) - 1; i >= 1; i-- {
+		if a, ok := c["*."+strings.Join(labels[i:], ".")]; ok {
+			out = out.merge(a)
+		}
+	}
+	if a, ok := c[host]; ok {
+		out = out.merge(a)
+	}
+	return out
+}
+
+// merge 合并两份配置，b 中的项优先
+func (a *HostAuth) merge(b *HostAuth) *HostAuth {
+	out := &HostAuth{Headers: map[string]string{}, Cookies: map[string]string{}}
+	for _, src := range []*HostAuth{a, b} {
+		if src == nil {
+			continue
+		}
+		for k, v := range src.Headers {
+			out.Headers[k] = v
+		}
+		for k, v := range src.Cookies {
+			out.Cookies[k] = v
+		}
+		if src.Username != "" {
+			out.Username, out.Password = src.Username, src.Password
+		}
+	}
+	return out
+}
+
+// apply 将配置写入请求，Cookie 不覆盖 Jar 中已有的同名项
+func (a *HostAuth) apply(req *http.Request) {
+	for k, v := range a.Headers {
+		if !isForbiddenHeader(k) {
+			req.Header.Set(k, v)
+		}
+	}
+	for name, value := range a.Cookies {
+		if _, err := req.Cookie(name); err != nil {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+	}
+	if a.Username != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+}
+
+// isForbiddenHeader 判断请求头是否禁止覆盖
+func isForbiddenHeader(name string) bool {
+	for _, h := range forbiddenHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// authTransport 按请求主机附加请求头、Cookie 与 Basic 认证，重定向到其他主机时不会带上
+type authTransport struct {
+	base http.RoundTripper
+	auth AuthConfig
+}
+
+// newAuthTransport 为 base 包装请求配置，auth 为空时直接返回 base
+func newAuthTransport(base http.RoundTripper, auth AuthConfig) http.RoundTripper {
+	if len(auth) == 0 {
+		return base
+	}
+	return &authTransport{base: base, auth: auth}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a := t.auth.lookup(strings.ToLower(req.URL.Hostname()))
+	if a == nil {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	a.apply(req)
+	return t.base.RoundTrip(req)
+}
+
+// newCookieJar 创建单次抓取使用的 Cookie Jar，使回退请求能沿用首次请求收到的 Cookie。
+// 不同调用之间不能共用，否则一次抓取收到的 Cookie 会发给其他调用方
+func newCookieJar() http.CookieJar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
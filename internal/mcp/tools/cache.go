@@ -0,0 +1,477 @@
+package tools
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 缓存状态，通过响应头 cacheHeader 传递给上层
+const (
+	cacheHeader = "X-Mcp-Cache"
+
+	CacheHit         = "hit"
+	CacheRevalidated = "revalidated"
+	CacheMiss        = "miss"
+)
+
+// cacheMaxEntrySize 单个响应可缓存的最大字节数
+const cacheMaxEntrySize = MaxBodySizeLimit
+
+// CacheEntry 缓存的响应
+type CacheEntry struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+	MaxAge   time.Duration
+}
+
+// fresh 判断缓存是否仍在 max-age 内
+func (e *CacheEntry) fresh() bool {
+	return time.Since(e.StoredAt) < e.MaxAge
+}
+
+// CacheStore 响应缓存存储
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, e *CacheEntry)
+}
+
+// defaultCache 进程级默认缓存，Vercel 上只使用内存
+var defaultCache CacheStore = NewMemoryCache(64, 32<<20)
+
+// SetDefaultCache 设置默认缓存，传入 nil 关闭缓存
+func SetDefaultCache(c CacheStore) {
+	defaultCache = c
+}
+
+// ==================== 内存 LRU ====================
+
+// MemoryCache 按条目数和总字节数淘汰的 LRU 内存缓存
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	size       int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache 创建内存缓存
+func NewMemoryCache(maxEntries, maxBytes int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get 读取缓存并标记为最近使用
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	m.ll.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+// Set 写入缓存并按容量淘汰
+func (m *MemoryCache) Set(key string, e *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.size -= len(el.Value.(*memoryItem).entry.Body)
+		el.Value.(*memoryItem).entry = e
+		m.ll.MoveToFront(el)
+	} else {
+		m.items[key] = m.ll.PushFront(&memoryItem{key: key, entry: e})
+	}
+	m.size += len(e.Body)
+
+	for m.ll.Len() > 0 && (m.ll.Len() > m.maxEntries || m.size > m.maxBytes) {
+		el := m.ll.Back()
+		item := el.Value.(*memoryItem)
+		m.ll.Remove(el)
+		delete(m.items, item.key)
+		m.size -= len(item.entry.Body)
+	}
+}
+
+// ==================== 磁盘 ====================
+
+// DiskCache 以文件形式保存在目录中的缓存，适用于常驻的 cmd/server。
+// 总大小超过 maxBytes 时按最近使用时间删除文件，写入时顺带清理已过期且无法重新验证的条目
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu        sync.Mutex
+	files     map[string]*diskFile // 缓存文件 → 大小与使用时间
+	size      int64
+	lastSweep time.Time
+}
+
+// diskFile 缓存文件的索引信息
+type diskFile struct {
+	size    int64
+	used    time.Time
+	expires time.Time // 没有验证器的条目过期后不能再使用；为零表示可以重新验证或未知
+}
+
+// diskSweepInterval 清理过期条目的最小间隔
+const diskSweepInterval = time.Minute
+
+// NewDiskCache 创建磁盘缓存，扫描目录中已有的文件建立索引，进程重启前的文件按修改时间排序
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DiskCache{dir: dir, maxBytes: maxBytes, files: make(map[string]*diskFile)}
+	err := filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil
+		}
+		// 写入中途退出留下的临时文件
+		if strings.HasPrefix(e.Name(), ".tmp-") {
+			if time.Since(info.ModTime()) > time.Minute {
+				os.Remove(p)
+			}
+			return nil
+		}
+		d.files[p] = &diskFile{size: info.Size(), used: info.ModTime()}
+		d.size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.evict("")
+	d.mu.Unlock()
+	return d, nil
+}
+
+// path 返回缓存键对应的文件路径
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// Get 读取缓存文件并更新使用时间，已过期且无法重新验证的条目直接删除
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	p := d.path(key)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, false
+	}
+	var e CacheEntry
+	err = gob.NewDecoder(f).Decode(&e)
+	f.Close()
+	if err != nil || !e.fresh() && !hasValidator(e.Header) {
+		d.remove(p)
+		return nil, false
+	}
+
+	now := time.Now()
+	d.mu.Lock()
+	if df, ok := d.files[p]; ok {
+		df.used = now
+	}
+	d.mu.Unlock()
+	// 修改时间记录最近使用，进程重启后仍按 LRU 淘汰
+	os.Chtimes(p, now, now)
+	return &e, true
+}
+
+// Set 先写临时文件再重命名，避免并发读到半个文件；写入后按容量淘汰
+func (d *DiskCache) Set(key string, e *CacheEntry) {
+	if int64(len(e.Body)) > d.maxBytes {
+		return
+	}
+	p := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return
+	}
+	if err := gob.NewEncoder(tmp).Encode(e); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	info, err := tmp.Stat()
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	df := &diskFile{size: info.Size(), used: time.Now()}
+	if !hasValidator(e.Header) {
+		df.expires = e.StoredAt.Add(e.MaxAge)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.files[p]; ok {
+		d.size -= old.size
+	}
+	d.files[p] = df
+	d.size += df.size
+	d.evict(p)
+}
+
+// remove 删除缓存文件
+func (d *DiskCache) remove(p string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(p)
+}
+
+// removeLocked 删除缓存文件，调用方持有 d.mu
+func (d *DiskCache) removeLocked(p string) {
+	if df, ok := d.files[p]; ok {
+		d.size -= df.size
+		delete(d.files, p)
+	}
+	os.Remove(p)
+}
+
+// evict 定期清理过期条目，总大小超过上限时删除最久未用的文件，keep 为刚写入的文件。调用方持有 d.mu
+func (d *DiskCache) evict(keep string) {
+	now := time.Now()
+	if now.Sub(d.lastSweep) >= diskSweepInterval {
+		d.lastSweep = now
+		for p, df := range d.files {
+			if !df.expires.IsZero() && now.After(df.expires) && p != keep {
+				d.removeLocked(p)
+			}
+		}
+	}
+	if d.size <= d.maxBytes {
+		return
+	}
+
+	paths := make([]string, 0, len(d.files))
+	for p := range d.files {
+		if p != keep {
+			paths = append(paths, p)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return d.files[paths[i]].used.Before(d.files[paths[j]].used) })
+	for _, p := range paths {
+		if d.size <= d.maxBytes {
+			break
+		}
+		d.removeLocked(p)
+	}
+}
+
+// hasValidator 判断响应头是否带有可用于重新验证的 ETag 或 Last-Modified
+func hasValidator(h http.Header) bool {
+	return h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// ==================== 缓存 Transport ====================
+
+// cacheTransport 带 ETag / Last-Modified 重新验证的缓存 RoundTripper
+type cacheTransport struct {
+	base  http.RoundTripper
+	store CacheStore
+}
+
+// newCacheTransport 为 base 包装缓存，store 为 nil 时直接返回 base
+func newCacheTransport(base http.RoundTripper, store CacheStore) http.RoundTripper {
+	if store == nil {
+		return base
+	}
+	return &cacheTransport{base: base, store: store}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := normalizeURL(req.URL.String())
+	cached, ok := t.store.Get(key)
+	if ok && cached.fresh() {
+		return cachedResponse(req, cached, CacheHit), nil
+	}
+
+	// 有缓存但已过期时带上验证器
+	if ok {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := cached.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		updated := *cached
+		updated.StoredAt = time.Now()
+		if maxAge, _, found := cacheLifetime(resp.Header); found {
+			updated.MaxAge = maxAge
+		}
+		t.store.Set(key, &updated)
+		return cachedResponse(req, &updated, CacheRevalidated), nil
+	}
+
+	resp.Header.Set(cacheHeader, CacheMiss)
+	maxAge, store, _ := cacheLifetime(resp.Header)
+	if resp.StatusCode != http.StatusOK || !store || (maxAge <= 0 && !hasValidator(resp.Header)) {
+		return resp, nil
+	}
+	if resp.ContentLength > cacheMaxEntrySize {
+		return resp, nil
+	}
+
+	// 读取至上限，超出时放弃缓存并把已读部分拼回去
+	body, err := io.ReadAll(io.LimitReader(resp.Body, cacheMaxEntrySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > cacheMaxEntrySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	// 重试记录只属于本次抓取，不写入缓存
+	header := resp.Header.Clone()
+	header.Del(retryHeader)
+	t.store.Set(key, &CacheEntry{
+		Status:   resp.StatusCode,
+		Header:   header,
+		Body:     body,
+		StoredAt: time.Now(),
+		MaxAge:   maxAge,
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// cachedResponse 由缓存条目构造响应
+func cachedResponse(req *http.Request, e *CacheEntry, status string) *http.Response {
+	header := e.Header.Clone()
+	header.Del(retryHeader) // 兼容旧版本写入磁盘的条目
+	header.Set(cacheHeader, status)
+	return &http.Response{
+		Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheLifetime 解析 Cache-Control / Expires，返回 max-age、是否允许存储以及是否给出了有效期
+func cacheLifetime(h http.Header) (maxAge time.Duration, store bool, found bool) {
+	store = true
+	noCache := false
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			store = false
+		case "no-cache":
+			noCache = true
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge, found = time.Duration(secs)*time.Second, true
+			}
+		}
+	}
+	if noCache {
+		return 0, store, true
+	}
+	if found {
+		return maxAge, store, true
+	}
+
+	if exp, err := http.ParseTime(h.Get("Expires")); err == nil {
+		return max(time.Until(exp), 0), store, true
+	}
+	return 0, store, false
+}
+
+// normalizeURL 规范化 URL 作为缓存键：小写协议与主机、去掉默认端口和片段、查询参数排序
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
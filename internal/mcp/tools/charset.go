@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/saintfish/chardet"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// metaCharsetRe 匹配 <meta charset> 与 http-equiv Content-Type 中的编码声明
+var metaCharsetRe = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([\w.:-]+)`)
+
+// metaSniffLimit 查找 <meta charset> 时扫描的字节数
+const metaSniffLimit = 4096
+
+// toUTF8 按 Content-Type、<meta charset>、字节嗅探的顺序识别编码并转码为 UTF-8
+func toUTF8(body []byte, contentType string) []byte {
+	enc := detectCharset(body, contentType)
+	if enc == nil {
+		return body
+	}
+	out, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// fixCharset 为采集器注册转码回调，colly 自身只处理 Content-Type 中声明的编码
+func fixCharset(c *colly.Collector) {
+	c.OnResponse(func(r *colly.Response) {
+		ct := r.Headers.Get("Content-Type")
+		if ct != "" && !strings.Contains(strings.ToLower(ct), "html") {
+			return
+		}
+		if _, params, err := mime.ParseMediaType(ct); err == nil && params["charset"] != "" {
+			return
+		}
+		// 缺少 Content-Type 的 PDF 等文档不能按文本转码
+		if detectDocType(r.Request.URL.String(), ct, r.Body) != DocHTML {
+			return
+		}
+		r.Body = toUTF8(r.Body, "")
+	})
+}
+
+// detectCharset 识别 HTML 的字符编码，UTF-8 或无法识别时返回 nil
+func detectCharset(body []byte, contentType string) encoding.Encoding {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if enc := lookupCharset(params["charset"]); enc != nil {
+			return enc
+		}
+	}
+
+	head := body[:min(len(body), metaSniffLimit)]
+	if m := metaCharsetRe.FindSubmatch(head); m != nil {
+		if enc := lookupCharset(string(m[1])); enc != nil {
+			return enc
+		}
+	}
+
+	if utf8.Valid(body) {
+		return nil
+	}
+
+	// 最后交给 chardet 按字节统计猜测
+	if r, err := chardet.NewHtmlDetector().DetectBest(body); err == nil {
+		return lookupCharset(r.Charset)
+	}
+	return nil
+}
+
+// lookupCharset 按 WHATWG 名称查找编码，兼容 chardet 的 GB-18030 等写法
+func lookupCharset(name string) encoding.Encoding {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	enc, canonical := charset.Lookup(name)
+	if enc == nil {
+		enc, canonical = charset.Lookup(strings.ReplaceAll(name, "-", ""))
+	}
+	if enc == nil || canonical == "utf-8" {
+		return nil
+	}
+	return enc
+}
@@ -0,0 +1,368 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-server/internal/outbound"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// 爬取范围
+const (
+	ScopeDomain = "domain" // 与起始 URL 同域名
+	ScopePrefix = "prefix" // 与起始 URL 同路径前缀
+)
+
+// 工具调用的服务端上限
+const (
+	CrawlDepthLimit = 5
+	CrawlPagesLimit = 100
+)
+
+// CrawlOptions 爬取选项
+type CrawlOptions struct {
+	Depth       int             // 最大链接跳数（0 表示只抓起始页）
+	MaxPages    int             // 最大页面数
+	Scope       string          // 爬取范围：domain / prefix
+	Prefix      string          // prefix 范围的路径前缀（默认取起始 URL 所在目录）
+	Include     []string        // URL 必须匹配的正则（任一）
+	Exclude     []string        // URL 排除的正则（任一）
+	Parallelism int             // 每个域名的并发数
+	Delay       time.Duration   // 同域名请求间隔
+	MapOnly     bool            // 只返回站点地图，不返回 Markdown
+	Robots      bool            // 遵守 robots.txt 的 Disallow 与 Crawl-delay
+	Limits      *ScraperOptions // 单个页面的大小与重定向限制，Timeout 为整次爬取的总时限
+}
+
+// DefaultCrawlOptions 默认爬取选项
+func DefaultCrawlOptions() *CrawlOptions {
+	return &CrawlOptions{
+		Depth:       2,
+		MaxPages:    20,
+		Scope:       ScopeDomain,
+		Parallelism: 2,
+		Limits:      DefaultScraperOptions(),
+	}
+}
+
+// CrawlPage 爬取到的单个页面
+type CrawlPage struct {
+	URL      string   `json:"url"`
+	Depth    int      `json:"depth"`
+	Title    string   `json:"title,omitempty"`
+	Markdown string   `json:"markdown,omitempty"`
+	Links    []string `json:"links,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// CrawlResult 爬取结果
+type CrawlResult struct {
+	StartURL  string      `json:"start_url"`
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated"`
+	SiteMap   []string    `json:"site_map"`
+	Pages     []CrawlPage `json:"pages"`
+}
+
+// Crawler 多页面爬虫
+type Crawler struct {
+	opts *CrawlOptions
+}
+
+// NewCrawler 创建爬虫
+func NewCrawler() *Crawler {
+	return &Crawler{opts: DefaultCrawlOptions()}
+}
+
+// Depth 设置最大链接跳数
+func (c *Crawler) Depth(n int) *Crawler {
+	c.opts.Depth = n
+	return c
+}
+
+// MaxPages 设置最大页面数
+func (c *Crawler) MaxPages(n int) *Crawler {
+	c.opts.MaxPages = n
+	return c
+}
+
+// Scope 设置爬取范围
+func (c *Crawler) Scope(scope string) *Crawler {
+	c.opts.Scope = scope
+	return c
+}
+
+// Prefix 设置路径前缀，并切换到 prefix 范围
+func (c *Crawler) Prefix(prefix string) *Crawler {
+	c.opts.Scope = ScopePrefix
+	c.opts.Prefix = prefix
+	return c
+}
+
+// Include 设置 URL 包含正则
+func (c *Crawler) Include(patterns ...string) *Crawler {
+	c.opts.Include = patterns
+	return c
+}
+
+// Exclude 设置 URL 排除正则
+func (c *Crawler) Exclude(patterns ...string) *Crawler {
+	c.opts.Exclude = patterns
+	return c
+}
+
+// Parallelism 设置每个域名的并发数
+func (c *Crawler) Parallelism(n int) *Crawler {
+	c.opts.Parallelism = n
+	return c
+}
+
+// Delay 设置同域名请求间隔
+func (c *Crawler) Delay(d time.Duration) *Crawler {
+	c.opts.Delay = d
+	return c
+}
+
+// MapOnly 设置只返回站点地图
+func (c *Crawler) MapOnly(v bool) *Crawler {
+	c.opts.MapOnly = v
+	return c
+}
+
+// RespectRobots 设置是否遵守 robots.txt
+func (c *Crawler) RespectRobots(v bool) *Crawler {
+	c.opts.Robots = v
+	return c
+}
+
+// Limits 设置单个页面的抓取限制
+func (c *Crawler) Limits(opts *ScraperOptions) *Crawler {
+	c.opts.Limits = opts
+	return c
+}
+
+// Crawl 从起始 URL 开始爬取
+func (c *Crawler) Crawl(startURL string) (*CrawlResult, error) {
+	start, err := url.Parse(startURL)
+	if err != nil || start.Host == "" {
+		return nil, fmt.Errorf("invalid start URL: %s", startURL)
+	}
+	if err := outbound.CheckURL(startURL); err != nil {
+		return nil, err
+	}
+
+	switch c.opts.Scope {
+	case "", ScopeDomain, ScopePrefix:
+	default:
+		return nil, fmt.Errorf("invalid scope: %q", c.opts.Scope)
+	}
+
+	include, err := compilePatterns(c.opts.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(c.opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := c.opts.Prefix
+	if c.opts.Scope == ScopePrefix && prefix == "" {
+		prefix = start.Path[:strings.LastIndex(start.Path, "/")+1]
+	}
+
+	// Limits.Timeout 是整次爬取的总时限：到期后不再发起新请求，进行中的请求随 context 取消
+	deadline := time.Now().Add(c.opts.Limits.Timeout)
+	crawlCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	collector := colly.NewCollector(
+		colly.MaxDepth(c.opts.Depth+1),
+		colly.Async(true),
+		colly.StdlibContext(crawlCtx),
+	)
+	fixCharset(collector)
+	c.opts.Limits.apply(collector)
+	collector.OnResponseHeaders(func(r *colly.Response) {
+		if err := c.opts.Limits.checkHeaders(*r.Headers); err != nil {
+			r.Request.Abort()
+		}
+	})
+
+	parallelism, delay := max(c.opts.Parallelism, 1), c.opts.Delay
+	if c.opts.Robots {
+		collector.IgnoreRobotsTxt = false
+		crawlDelay, err := checkRobots(start.String(), collector.UserAgent, c.opts.Limits.withTimeout(time.Until(deadline)))
+		if err != nil {
+			return nil, err
+		}
+		// 存在 crawl-delay 时串行抓取
+		if crawlDelay > 0 {
+			parallelism, delay = 1, max(delay, crawlDelay)
+		}
+	}
+
+	if err := collector.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: parallelism,
+		Delay:       delay,
+	}); err != nil {
+		return nil, fmt.Errorf("set limit rule failed: %w", err)
+	}
+
+	result := &CrawlResult{StartURL: startURL}
+	var mu sync.Mutex
+	requested := 0
+
+	// inScope 判断链接是否在爬取范围内
+	inScope := func(u *url.URL) bool {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return false
+		}
+		if !strings.EqualFold(u.Hostname(), start.Hostname()) {
+			return false
+		}
+		if c.opts.Scope == ScopePrefix && !strings.HasPrefix(u.Path, prefix) {
+			return false
+		}
+		s := u.String()
+		if len(include) > 0 && !matchAny(include, s) {
+			return false
+		}
+		return !matchAny(exclude, s)
+	}
+
+	collector.OnRequest(func(r *colly.Request) {
+		if outbound.CheckURL(r.URL.String()) != nil {
+			r.Abort()
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Now().After(deadline) {
+			result.Truncated = true
+			r.Abort()
+			return
+		}
+		if c.opts.MaxPages > 0 && requested >= c.opts.MaxPages {
+			result.Truncated = true
+			r.Abort()
+			return
+		}
+		requested++
+	})
+
+	collector.OnHTML("title", func(e *colly.HTMLElement) {
+		e.Request.Ctx.Put("title", strings.TrimSpace(e.Text))
+	})
+
+	collector.OnHTML("body", func(e *colly.HTMLElement) {
+		if !c.opts.MapOnly {
+			e.Request.Ctx.Put("markdown", extractContent(e))
+		}
+	})
+
+	collector.OnHTML("a[href]", func(e *colly.HTMLElement) {
+		link, err := url.Parse(e.Request.AbsoluteURL(e.Attr("href")))
+		if err != nil || link.Host == "" {
+			return
+		}
+		link.Fragment = ""
+		if !inScope(link) {
+			return
+		}
+
+		links, _ := e.Request.Ctx.GetAny("links").([]string)
+		if !slices.Contains(links, link.String()) {
+			e.Request.Ctx.Put("links", append(links, link.String()))
+		}
+		_ = e.Request.Visit(link.String())
+	})
+
+	collector.OnScraped(func(r *colly.Response) {
+		title := r.Ctx.Get("title")
+		page := CrawlPage{
+			URL:   r.Request.URL.String(),
+			Depth: r.Request.Depth - 1,
+			Title: title,
+		}
+		if md := r.Ctx.Get("markdown"); md != "" {
+			page.Markdown = formatMarkdown(title, md)
+		}
+		page.Links, _ = r.Ctx.GetAny("links").([]string)
+
+		mu.Lock()
+		result.Pages = append(result.Pages, page)
+		mu.Unlock()
+	})
+
+	collector.OnError(func(r *colly.Response, err error) {
+		mu.Lock()
+		if crawlCtx.Err() != nil {
+			result.Truncated = true
+		}
+		result.Pages = append(result.Pages, CrawlPage{
+			URL:   r.Request.URL.String(),
+			Depth: r.Request.Depth - 1,
+			Error: err.Error(),
+		})
+		mu.Unlock()
+	})
+
+	if err := collector.Visit(start.String()); err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	collector.Wait()
+
+	// 按深度和 URL 排序，保证输出稳定
+	sort.Slice(result.Pages, func(i, j int) bool {
+		if result.Pages[i].Depth != result.Pages[j].Depth {
+			return result.Pages[i].Depth < result.Pages[j].Depth
+		}
+		return result.Pages[i].URL < result.Pages[j].URL
+	})
+
+	result.SiteMap = make([]string, 0, len(result.Pages))
+	for _, p := range result.Pages {
+		if p.Error == "" {
+			result.SiteMap = append(result.SiteMap, p.URL)
+		}
+	}
+	sort.Strings(result.SiteMap)
+	result.Count = len(result.Pages)
+
+	return result, nil
+}
+
+// compilePatterns 编译正则列表
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchAny 判断字符串是否匹配任一正则
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
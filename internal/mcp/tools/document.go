@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// 抓取结果的文档类型
+const (
+	DocHTML     = "html"
+	DocPDF      = "pdf"
+	DocMarkdown = "markdown"
+	DocText     = "text"
+	DocJSON     = "json"
+)
+
+// detectDocType 按 Content-Type、URL 扩展名与内容嗅探识别文档类型
+func detectDocType(rawURL, contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	ext := urlExt(rawURL)
+
+	switch {
+	case mediaType == "application/pdf" || bytes.HasPrefix(body, []byte("%PDF-")):
+		return DocPDF
+	case mediaType == "text/markdown" || mediaType == "text/x-markdown":
+		return DocMarkdown
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return DocJSON
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return DocHTML
+	case mediaType == "text/plain":
+		// raw.githubusercontent.com 等以 text/plain 返回 Markdown 与 JSON
+		switch ext {
+		case ".md", ".markdown":
+			return DocMarkdown
+		case ".json":
+			return DocJSON
+		}
+		return DocText
+	}
+
+	// 缺失或笼统的 Content-Type 时按扩展名与内容判断
+	switch ext {
+	case ".pdf":
+		return DocPDF
+	case ".md", ".markdown":
+		return DocMarkdown
+	case ".json":
+		return DocJSON
+	case ".txt":
+		return DocText
+	}
+	if sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(body)); sniffed == "text/plain" && json.Valid(bytes.TrimSpace(body)) {
+		return DocJSON
+	}
+	return DocHTML
+}
+
+// urlExt 返回 URL 路径的小写扩展名
+func urlExt(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+// extractDocument 将非 HTML 文档转换为 Markdown，contentType 用于识别文本编码
+func extractDocument(rawURL, docType, contentType string, body []byte, opts *ScraperOptions) (*ScrapeResult, error) {
+	result := &ScrapeResult{URL: rawURL, Type: docType, Title: path.Base(strings.TrimSuffix(rawURL, "/"))}
+
+	switch docType {
+	case DocPDF:
+		title, md, err := extractPDF(body)
+		if err != nil {
+			if opts.MaxBodySize > 0 && len(body) >= opts.MaxBodySize {
+				return nil, fmt.Errorf("%w (body truncated at %d bytes, raise max_bytes)", err, opts.MaxBodySize)
+			}
+			return nil, err
+		}
+		if title != "" {
+			result.Title = title
+		}
+		result.Markdown = formatMarkdown(result.Title, md)
+	case DocJSON:
+		var out bytes.Buffer
+		if err := json.Indent(&out, bytes.TrimSpace(body), "", "  "); err != nil {
+			// 非法 JSON 原样返回
+			out.Reset()
+			out.Write(body)
+		}
+		result.Markdown = "```json\n" + strings.TrimRight(out.String(), "\n") + "\n```\n"
+	case DocMarkdown:
+		result.Markdown = string(toUTF8(body, contentType))
+		if title := markdownTitle(result.Markdown); title != "" {
+			result.Title = title
+		}
+	default:
+		result.Markdown = string(toUTF8(body, contentType))
+	}
+	return result, nil
+}
+
+// extractPDF 逐页提取 PDF 文本，每页前加页码标题
+func extractPDF(body []byte) (title, md string, err error) {
+	// pdf 库遇到损坏的文件可能 panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parse pdf failed: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", "", fmt.Errorf("parse pdf failed: %w", err)
+	}
+
+	title = strings.TrimSpace(r.Trailer().Key("Info").Key("Title").Text())
+
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", "", fmt.Errorf("extract pdf page %d failed: %w", i, err)
+		}
+		fmt.Fprintf(&sb, "## 第 %d 页\n\n%s\n\n", i, strings.TrimSpace(text))
+	}
+	return title, sb.String(), nil
+}
+
+// markdownTitle 返回 Markdown 中第一个一级标题
+func markdownTitle(md string) string {
+	for _, line := range strings.Split(md, "\n") {
+		if t, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			return strings.TrimSpace(t)
+		}
+	}
+	return ""
+}
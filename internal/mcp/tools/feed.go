@@ -0,0 +1,529 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// FeedLimit 工具调用返回条目数的服务端上限
+const FeedLimit = 200
+
+// FeedEntry 订阅源条目
+type FeedEntry struct {
+	ID      string `json:"id,omitempty"`
+	Title   string `json:"title"`
+	Link    string `json:"link,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Summary string `json:"summary,omitempty"`
+
+	date time.Time
+}
+
+// FeedResult 订阅源读取结果
+type FeedResult struct {
+	FeedURL string      `json:"feed_url"`
+	Format  string      `json:"format"`
+	Title   string      `json:"title"`
+	Link    string      `json:"link,omitempty"`
+	Count   int         `json:"count"`
+	Entries []FeedEntry `json:"entries"`
+}
+
+// FeedOptions 订阅源选项
+type FeedOptions struct {
+	Since time.Time // 只保留此时间之后的条目
+	Limit int       // 最多返回的条目数
+}
+
+// rssDoc RSS 2.0 文档
+type rssDoc struct {
+	Channel struct {
+		Title string   `xml:"title"`
+		Links []string `xml:"link"`
+		Items []struct {
+			Title       string   `xml:"title"`
+			Links       []string `xml:"link"`
+			GUID        string   `xml:"guid"`
+			PubDate     string   `xml:"pubDate"`
+			DCDate      string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+			Description string   `xml:"description"`
+			Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// atomLink Atom 链接
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// atomText Atom 文本内容，type="xhtml" 时内容是内联的 XHTML 元素而不是转义后的文本
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// xhtmlPrefixRe 匹配带命名空间前缀的标签名，如 <xhtml:div>
+var xhtmlPrefixRe = regexp.MustCompile(`(</?)[A-Za-z][\w.-]*:`)
+
+// String 返回可交给 htmlToMarkdown 的内容：xhtml 取内联元素并去掉命名空间前缀，
+// html 取反转义后的 HTML，text 重新转义以免其中的尖括号被当作标签
+func (t atomText) String() string {
+	switch t.Type {
+	case "xhtml":
+		return xhtmlPrefixRe.ReplaceAllString(t.Inner, "$1")
+	case "html", "text/html":
+		return t.Text
+	default:
+		return html.EscapeString(t.Text)
+	}
+}
+
+// atomDoc Atom 文档
+type atomDoc struct {
+	Title   string     `xml:"title"`
+	Links   []atomLink `xml:"link"`
+	Entries []struct {
+		ID        string     `xml:"id"`
+		Title     string     `xml:"title"`
+		Links     []atomLink `xml:"link"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Summary   atomText   `xml:"summary"`
+		Content   atomText   `xml:"content"`
+	} `xml:"entry"`
+}
+
+// jsonFeedDoc JSON Feed 文档
+type jsonFeedDoc struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	Items       []struct {
+		ID            string `json:"id"`
+		URL           string `json:"url"`
+		Title         string `json:"title"`
+		Summary       string `json:"summary"`
+		ContentHTML   string `json:"content_html"`
+		ContentText   string `json:"content_text"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+// ReadFeed 读取订阅源，传入 HTML 页面时通过 <link rel="alternate"> 自动发现
+func ReadFeed(rawURL string, opts *FeedOptions) (*FeedResult, error) {
+	body, err := httpGetBody(rawURL, DefaultScraperOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseFeed(rawURL, body)
+	if err != nil {
+		feedURL, ok := discoverFeed(rawURL, body)
+		if !ok {
+			return nil, err
+		}
+		if body, err = httpGetBody(feedURL, DefaultScraperOptions()); err != nil {
+			return nil, err
+		}
+		if result, err = parseFeed(feedURL, body); err != nil {
+			return nil, err
+		}
+	}
+
+	// 按时间倒序，无日期的条目排在最后
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		return result.Entries[i].date.After(result.Entries[j].date)
+	})
+
+	entries := make([]FeedEntry, 0, len(result.Entries))
+	for _, e := range result.Entries {
+		if !opts.Since.IsZero() && (e.date.IsZero() || e.date.Before(opts.Since)) {
+			continue
+		}
+		if opts.Limit > 0 && len(entries) >= opts.Limit {
+			break
+		}
+		entries = append(entries, e)
+	}
+	result.Entries = entries
+	result.Count = len(entries)
+
+	return result, nil
+}
+
+// parseFeed 按内容识别并解析 RSS / Atom / JSON Feed
+func parseFeed(feedURL string, body []byte) (*FeedResult, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSONFeed(feedURL, trimmed)
+	}
+
+	root, err := xmlRootName(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("not a feed: %w", err)
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(feedURL, trimmed)
+	case "feed":
+		return parseAtom(feedURL, trimmed)
+	default:
+		return nil, fmt.Errorf("not a feed: unexpected root element <%s>", root)
+	}
+}
+
+// newXMLDecoder 创建支持非 UTF-8 编码声明的 XML 解码器
+func newXMLDecoder(body []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+	return dec
+}
+
+// xmlRootName 返回 XML 根元素名
+func xmlRootName(body []byte) (string, error) {
+	dec := newXMLDecoder(body)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return strings.ToLower(se.Name.Local), nil
+		}
+	}
+}
+
+// parseRSS 解析 RSS 2.0
+func parseRSS(feedURL string, body []byte) (*FeedResult, error) {
+	var doc rssDoc
+	if err := newXMLDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse rss failed: %w", err)
+	}
+
+	result := &FeedResult{
+		FeedURL: feedURL,
+		Format:  "rss",
+		Title:   strings.TrimSpace(doc.Channel.Title),
+		Link:    firstNonEmpty(doc.Channel.Links...),
+	}
+	for _, item := range doc.Channel.Items {
+		date := parseFeedDate(firstNonEmpty(item.PubDate, item.DCDate))
+		result.Entries = append(result.Entries, FeedEntry{
+			ID:      strings.TrimSpace(item.GUID),
+			Title:   strings.TrimSpace(item.Title),
+			Link:    resolveURL(feedURL, firstNonEmpty(item.Links...)),
+			Date:    formatFeedDate(date),
+			Summary: htmlToMarkdown(firstNonEmpty(item.Description, item.Content)),
+			date:    date,
+		})
+	}
+	return result, nil
+}
+
+// parseAtom 解析 Atom
+func parseAtom(feedURL string, body []byte) (*FeedResult, error) {
+	var doc atomDoc
+	if err := newXMLDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse atom failed: %w", err)
+	}
+
+	result := &FeedResult{
+		FeedURL: feedURL,
+		Format:  "atom",
+		Title:   strings.TrimSpace(doc.Title),
+		Link:    atomAlternate(doc.Links),
+	}
+	for _, entry := range doc.Entries {
+		date := parseFeedDate(firstNonEmpty(entry.Published, entry.Updated))
+		result.Entries = append(result.Entries, FeedEntry{
+			ID:      strings.TrimSpace(entry.ID),
+			Title:   strings.TrimSpace(entry.Title),
+			Link:    resolveURL(feedURL, atomAlternate(entry.Links)),
+			Date:    formatFeedDate(date),
+			Summary: htmlToMarkdown(firstNonEmpty(entry.Summary.String(), entry.Content.String())),
+			date:    date,
+		})
+	}
+	return result, nil
+}
+
+// parseJSONFeed 解析 JSON Feed
+func parseJSONFeed(feedURL string, body []byte) (*FeedResult, error) {
+	var doc jsonFeedDoc
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse json feed failed: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("not a feed: missing JSON Feed version")
+	}
+
+	result := &FeedResult{
+		FeedURL: feedURL,
+		Format:  "json",
+		Title:   strings.TrimSpace(doc.Title),
+		Link:    doc.HomePageURL,
+	}
+	for _, item := range doc.Items {
+		date := parseFeedDate(firstNonEmpty(item.DatePublished, item.DateModified))
+		summary := item.ContentText
+		if item.Summary != "" || item.ContentHTML != "" {
+			summary = htmlToMarkdown(firstNonEmpty(item.Summary, item.ContentHTML))
+		}
+		result.Entries = append(result.Entries, FeedEntry{
+			ID:      item.ID,
+			Title:   strings.TrimSpace(item.Title),
+			Link:    resolveURL(feedURL, item.URL),
+			Date:    formatFeedDate(date),
+			Summary: strings.TrimSpace(summary),
+			date:    date,
+		})
+	}
+	return result, nil
+}
+
+// discoverFeed 从 HTML 页面的 <link rel="alternate"> 中发现订阅源
+func discoverFeed(pageURL string, body []byte) (string, bool) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+
+	var found string
+	doc.Find(`link[rel~="alternate"][href]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		switch strings.ToLower(sel.AttrOr("type", "")) {
+		case "application/rss+xml", "application/atom+xml", "application/feed+json", "application/json":
+			found = resolveURL(pageURL, sel.AttrOr("href", ""))
+			return false
+		}
+		return true
+	})
+	return found, found != ""
+}
+
+// atomAlternate 选择 Atom 的 alternate 链接
+func atomAlternate(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// feedDateLayouts 订阅源中常见的日期格式
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedDate 解析日期，失败时返回零值
+func parseFeedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// formatFeedDate 统一输出 RFC3339
+func formatFeedDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseSince 解析 since 参数，支持 RFC3339 与 2006-01-02
+func ParseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t := parseFeedDate(s); !t.IsZero() {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", s)
+}
+
+// htmlToMarkdown 将条目摘要的 HTML 转换为 Markdown，按文档顺序保留正文、链接与强调，纯文本原样保留
+func htmlToMarkdown(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return s
+	}
+	var sb strings.Builder
+	for _, n := range doc.Find("body").Nodes {
+		writeChildrenMarkdown(&sb, n)
+	}
+	if md := tidyMarkdown(sb.String()); md != "" {
+		return md
+	}
+	return cleanText(doc.Text())
+}
+
+// blockTags 前后需要空行分隔的块级元素
+var blockTags = map[string]bool{
+	"p": true, "div": true, "blockquote": true, "section": true, "article": true, "figure": true,
+	"header": true, "footer": true, "ul": true, "ol": true, "dl": true, "table": true, "tr": true, "hr": true,
+}
+
+// whitespaceRe 匹配连续空白
+var whitespaceRe = regexp.MustCompile(`\s+`)
+
+// writeMarkdown 把节点写为 Markdown，行内文本保留在原位置，块级元素之间以空行分隔
+func writeMarkdown(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(whitespaceRe.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+	default:
+		writeChildrenMarkdown(sb, n)
+		return
+	}
+
+	switch tag := n.Data; tag {
+	case "script", "style", "noscript", "template":
+	case "br":
+		sb.WriteString("\n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := innerMarkdown(n); text != "" {
+			fmt.Fprintf(sb, "\n\n%s %s\n\n", strings.Repeat("#", int(tag[1]-'0')), text)
+		}
+	case "pre":
+		if text := strings.Trim(goquery.NewDocumentFromNode(n).Text(), "\n"); text != "" {
+			sb.WriteString("\n\n```\n" + text + "\n```\n\n")
+		}
+	case "code":
+		if text := cleanText(goquery.NewDocumentFromNode(n).Text()); text != "" {
+			sb.WriteString("`" + text + "`")
+		}
+	case "strong", "b":
+		writeWrapped(sb, n, "**")
+	case "em", "i":
+		writeWrapped(sb, n, "*")
+	case "a":
+		text, href := innerMarkdown(n), nodeAttr(n, "href")
+		if text != "" && href != "" && !strings.HasPrefix(href, "#") {
+			fmt.Fprintf(sb, "[%s](%s)", text, href)
+		} else {
+			sb.WriteString(text)
+		}
+	case "img":
+		if src := nodeAttr(n, "src"); src != "" {
+			fmt.Fprintf(sb, "![%s](%s)", cleanText(nodeAttr(n, "alt")), src)
+		}
+	case "li":
+		if text := innerMarkdown(n); text != "" {
+			sb.WriteString("\n- " + text)
+		}
+	default:
+		if blockTags[tag] {
+			sb.WriteString("\n\n")
+			writeChildrenMarkdown(sb, n)
+			sb.WriteString("\n\n")
+		} else {
+			writeChildrenMarkdown(sb, n)
+		}
+	}
+}
+
+// writeChildrenMarkdown 依次写入子节点
+func writeChildrenMarkdown(sb *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeMarkdown(sb, c)
+	}
+}
+
+// writeWrapped 用 marker 包裹元素内容，如 **粗体**，两侧的空格保留在外面
+func writeWrapped(sb *strings.Builder, n *html.Node, marker string) {
+	var inner strings.Builder
+	writeChildrenMarkdown(&inner, n)
+	s := inner.String()
+	text := strings.TrimSpace(s)
+	if text == "" {
+		sb.WriteString(s)
+		return
+	}
+	if strings.HasPrefix(s, " ") {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(marker + text + marker)
+	if strings.HasSuffix(s, " ") {
+		sb.WriteString(" ")
+	}
+}
+
+// innerMarkdown 返回元素内容单行的 Markdown
+func innerMarkdown(n *html.Node) string {
+	var sb strings.Builder
+	writeChildrenMarkdown(&sb, n)
+	return cleanText(sb.String())
+}
+
+// nodeAttr 读取元素属性
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// tidyMarkdown 去掉行首尾空白并合并连续空行，代码块内原样保留
+func tidyMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			lines[i] = trimmed
+		} else if !inFence {
+			lines[i] = cleanText(line)
+		}
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package tools
+
+import "testing"
+
+func TestParseFeedSummary(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want string
+	}{
+		"rss mixed text and link": {
+			body: `<?xml version="1.0"?><rss version="2.0"><channel><title>T</title><item><title>v2</title>
+<description>We shipped v2 today. See &lt;a href="https://example.com/notes"&gt;the notes&lt;/a&gt;</description></item></channel></rss>`,
+			want: "We shipped v2 today. See [the notes](https://example.com/notes)",
+		},
+		"json feed paragraphs and list": {
+			body: `{"version":"https://jsonfeed.org/version/1.1","title":"T","items":[{"id":"1",
+"content_html":"<p>Intro with <strong>bold</strong> text.</p>Loose text<ul><li>one</li><li>two</li></ul>"}]}`,
+			want: "Intro with **bold** text.\n\nLoose text\n\n- one\n- two",
+		},
+		"atom xhtml": {
+			body: `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>T</title><entry><id>1</id><title>x</title>
+<content type="xhtml"><xhtml:div xmlns:xhtml="http://www.w3.org/1999/xhtml">Hello <xhtml:a href="/a">there</xhtml:a> &amp; bye</xhtml:div></content></entry></feed>`,
+			want: "Hello [there](/a) & bye",
+		},
+		"atom text": {
+			body: `<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>T</title><entry><id>1</id><title>x</title>
+<summary>a &lt;b&gt; c</summary></entry></feed>`,
+			want: "a <b> c",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := parseFeed("https://example.com/feed", []byte(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(result.Entries))
+			}
+			if got := result.Entries[0].Summary; got != tc.want {
+				t.Errorf("summary = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
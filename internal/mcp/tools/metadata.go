@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// metaReadLimit 仅抓取元数据时最多读取的字节数
+const metaReadLimit = 512 << 10
+
+// Metadata 页面元数据
+type Metadata struct {
+	Description string            `json:"description,omitempty"`
+	Canonical   string            `json:"canonical,omitempty"`
+	Language    string            `json:"language,omitempty"`
+	Author      string            `json:"author,omitempty"`
+	Published   string            `json:"published,omitempty"`
+	Modified    string            `json:"modified,omitempty"`
+	OpenGraph   map[string]string `json:"open_graph,omitempty"`
+	Twitter     map[string]string `json:"twitter,omitempty"`
+	JSONLD      []any             `json:"json_ld,omitempty"`
+}
+
+// extractMetadata 从 HTML 文档提取元数据
+func extractMetadata(doc *goquery.Selection, pageURL string) *Metadata {
+	meta := &Metadata{
+		OpenGraph: map[string]string{},
+		Twitter:   map[string]string{},
+	}
+
+	// colly 回调传入的即是 <html> 元素本身
+	root := doc
+	if !doc.Is("html") {
+		root = doc.Find("html").First()
+	}
+	meta.Language = strings.TrimSpace(root.AttrOr("lang", ""))
+
+	doc.Find("meta").Each(func(_ int, sel *goquery.Selection) {
+		content := strings.TrimSpace(sel.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+
+		// OpenGraph 使用 property，Twitter 与常规 meta 使用 name
+		key := strings.ToLower(sel.AttrOr("property", ""))
+		if key == "" {
+			key = strings.ToLower(sel.AttrOr("name", ""))
+		}
+		if key == "" {
+			if strings.EqualFold(sel.AttrOr("http-equiv", ""), "content-language") && meta.Language == "" {
+				meta.Language = content
+			}
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(key, "og:"):
+			meta.OpenGraph[strings.TrimPrefix(key, "og:")] = content
+		case strings.HasPrefix(key, "twitter:"):
+			meta.Twitter[strings.TrimPrefix(key, "twitter:")] = content
+		case key == "description":
+			meta.Description = content
+		case key == "author", key == "article:author":
+			setIfEmpty(&meta.Author, content)
+		case key == "article:published_time", key == "date", key == "dc.date", key == "dcterms.created":
+			setIfEmpty(&meta.Published, content)
+		case key == "article:modified_time", key == "last-modified", key == "dcterms.modified":
+			setIfEmpty(&meta.Modified, content)
+		}
+	})
+
+	if href, ok := doc.Find(`link[rel="canonical"]`).First().Attr("href"); ok {
+		meta.Canonical = resolveURL(pageURL, strings.TrimSpace(href))
+	}
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, sel *goquery.Selection) {
+		var v any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(sel.Text())), &v); err == nil {
+			meta.JSONLD = append(meta.JSONLD, v)
+		}
+	})
+
+	// 常规字段缺失时回退到 OpenGraph
+	setIfEmpty(&meta.Description, meta.OpenGraph["description"])
+	setIfEmpty(&meta.Canonical, meta.OpenGraph["url"])
+	setIfEmpty(&meta.Modified, meta.OpenGraph["updated_time"])
+	setIfEmpty(&meta.Author, meta.Twitter["creator"])
+
+	if len(meta.OpenGraph) == 0 {
+		meta.OpenGraph = nil
+	}
+	if len(meta.Twitter) == 0 {
+		meta.Twitter = nil
+	}
+	return meta
+}
+
+// setIfEmpty 目标为空时赋值
+func setIfEmpty(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
+
+// resolveURL 将相对地址解析为绝对地址
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// FetchMetadata 仅抓取页面元数据，只读取并解析 <head> 部分
+func FetchMetadata(pageURL string) (*Metadata, error) {
+	resp, err := httpGet(pageURL, DefaultScraperOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, metaReadLimit))
+	if err != nil {
+		return nil, fmt.Errorf("read body failed: %w", err)
+	}
+	if i := bytes.Index(bytes.ToLower(body), []byte("</head>")); i >= 0 {
+		body = body[:i+len("</head>")]
+	}
+	body = toUTF8(body, resp.Header.Get("Content-Type"))
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse html failed: %w", err)
+	}
+
+	return extractMetadata(doc.Selection, pageURL), nil
+}
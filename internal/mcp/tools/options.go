@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-server/internal/outbound"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// 工具调用的服务端上限
+const (
+	MaxBodySizeLimit  = 20 << 20
+	TimeoutLimit      = 25 * time.Second
+	MaxRedirectsLimit = 10
+)
+
+// ScraperOptions 抓取器选项
+type ScraperOptions struct {
+	MaxBodySize  int            // 响应体最大字节数，超出部分截断
+	Timeout      time.Duration  // 单次抓取（含回退）的总时限
+	MaxRedirects int            // 最大重定向次数
+	ContentTypes []string       // 允许的 Content-Type 前缀，为空表示不限制
+	Cache        CacheStore     // 响应缓存，为 nil 表示不缓存
+	Auth         AuthConfig     // 按主机附加的请求头、Cookie 与 Basic 认证
+	Jar          http.CookieJar // 两条抓取路径共用的 Cookie Jar，为 nil 时每次抓取新建
+	Retries      int            // 429 / 5xx 与网络错误的最大重试次数
+	HostInterval time.Duration  // 同一主机两次请求的最小间隔，进程内共享
+}
+
+// DefaultScraperOptions 默认抓取器选项
+func DefaultScraperOptions() *ScraperOptions {
+	return &ScraperOptions{
+		MaxBodySize:  5 << 20,
+		Timeout:      10 * time.Second,
+		MaxRedirects: 5,
+		Cache:        defaultCache,
+		Auth:         defaultAuth,
+		Retries:      2,
+		HostInterval: 250 * time.Millisecond,
+	}
+}
+
+// Clamp 按服务端上限裁剪选项，用于处理工具调用传入的参数
+func (o *ScraperOptions) Clamp() *ScraperOptions {
+	o.MaxBodySize = min(max(o.MaxBodySize, 1), MaxBodySizeLimit)
+	o.Timeout = min(max(o.Timeout, time.Second), TimeoutLimit)
+	o.MaxRedirects = min(max(o.MaxRedirects, 0), MaxRedirectsLimit)
+	o.Retries = min(max(o.Retries, 0), RetriesLimit)
+	o.HostInterval = min(max(o.HostInterval, 0), HostIntervalLimit)
+	return o
+}
+
+// withTimeout 返回替换了时限的副本
+func (o *ScraperOptions) withTimeout(d time.Duration) *ScraperOptions {
+	cp := *o
+	cp.Timeout = d
+	return &cp
+}
+
+// checkHeaders 根据响应头检查 Content-Type 白名单与声明的长度
+func (o *ScraperOptions) checkHeaders(h http.Header) error {
+	if n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil && o.MaxBodySize > 0 && n > int64(o.MaxBodySize) {
+		return fmt.Errorf("response too large: %d bytes exceeds limit %d", n, o.MaxBodySize)
+	}
+
+	if len(o.ContentTypes) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("content type not allowed: %q", h.Get("Content-Type"))
+	}
+	for _, allowed := range o.ContentTypes {
+		if strings.HasPrefix(mediaType, strings.ToLower(strings.TrimSpace(allowed))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("content type not allowed: %s", mediaType)
+}
+
+// redirectPolicy 返回限制重定向次数的 CheckRedirect 函数
+func (o *ScraperOptions) redirectPolicy() func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > o.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", o.MaxRedirects)
+		}
+		return outbound.CheckURL(req.URL.String())
+	}
+}
+
+// transport 返回按选项组装的 RoundTripper：缓存 → 认证 → 限速与重试 → 出站策略
+func (o *ScraperOptions) transport() http.RoundTripper {
+	rt := newRetryTransport(outbound.Transport(), o.Retries, o.HostInterval)
+	return newCacheTransport(newAuthTransport(rt, o.Auth), o.Cache)
+}
+
+// apply 将选项应用到 colly 采集器
+func (o *ScraperOptions) apply(c *colly.Collector) {
+	c.WithTransport(o.transport())
+	jar := o.Jar
+	if jar == nil {
+		jar = newCookieJar()
+	}
+	c.SetCookieJar(jar)
+	c.MaxBodySize = o.MaxBodySize
+	c.SetRequestTimeout(o.Timeout)
+	c.SetRedirectHandler(o.redirectPolicy())
+}
@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-server/internal/outbound"
+)
+
+// retryHeader 记录重试决策的响应头，每次重试一条
+const retryHeader = "X-Mcp-Retry"
+
+// 重试与限速的服务端上限
+const (
+	RetriesLimit      = 5
+	HostIntervalLimit = 5 * time.Second
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 10 * time.Second
+)
+
+// retryStatus 可重试的状态码
+var retryStatus = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// hostLimiter 进程级的按主机限速器，同一主机的请求间隔不小于 interval
+type hostLimiter struct {
+	mu   sync.Mutex
+	last map[string]time.Time // 主机最近一次放行请求的时间
+}
+
+// defaultLimiter 所有抓取共用的限速器
+var defaultLimiter = &hostLimiter{last: make(map[string]time.Time)}
+
+// wait 等待到距主机上次请求至少 interval 后放行。只在放行时记录时间，
+// 等待中被取消的调用不会占用之后的时间，也不会推迟其他调用方
+func (l *hostLimiter) wait(ctx context.Context, host string, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+	interval = min(interval, HostIntervalLimit)
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if len(l.last) > 1024 {
+			// 清理一分钟内没有请求的主机，避免长期运行时无限增长
+			for h, t := range l.last {
+				if now.Sub(t) > time.Minute {
+					delete(l.last, h)
+				}
+			}
+		}
+		next := l.last[host].Add(interval)
+		if !next.After(now) {
+			l.last[host] = now
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		if err := sleep(ctx, next.Sub(now)); err != nil {
+			return err
+		}
+	}
+}
+
+// retryTransport 按主机限速，并对 429 / 5xx 与网络错误按指数退避重试
+type retryTransport struct {
+	base     http.RoundTripper
+	retries  int
+	interval time.Duration
+}
+
+// newRetryTransport 为 base 包装限速与重试，interval 为 0 时不限速
+func newRetryTransport(base http.RoundTripper, retries int, interval time.Duration) http.RoundTripper {
+	return &retryTransport{base: base, retries: retries, interval: interval}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := strings.ToLower(req.URL.Hostname())
+	var decisions []string
+
+	for attempt := 0; ; attempt++ {
+		if err := defaultLimiter.wait(ctx, host, t.interval); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		reason, retryable := retryReason(resp, err)
+		if !retryable || attempt >= t.retries || (req.Body != nil && req.GetBody == nil) {
+			if err != nil {
+				if len(decisions) > 0 {
+					return nil, fmt.Errorf("%w (after %d retries)", err, len(decisions))
+				}
+				return nil, err
+			}
+			for _, d := range decisions {
+				resp.Header.Add(retryHeader, d)
+			}
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header); ok {
+				delay = after
+			}
+		}
+		// 等待超过上限或会越过截止时间时放弃重试，返回最后一次结果
+		if deadline, ok := ctx.Deadline(); delay > retryMaxDelay || (ok && time.Now().Add(delay).After(deadline)) {
+			decisions = append(decisions, fmt.Sprintf("%s, gave up: retry after %s exceeds deadline", reason, delay.Round(time.Millisecond)))
+			if err != nil {
+				return nil, fmt.Errorf("%w (%s)", err, decisions[len(decisions)-1])
+			}
+			for _, d := range decisions {
+				resp.Header.Add(retryHeader, d)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		decisions = append(decisions, fmt.Sprintf("%s, retry %d after %s", reason, attempt+1, delay.Round(time.Millisecond)))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryReason 判断响应或错误是否可重试，并给出原因
+func retryReason(resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if errors.Is(err, outbound.ErrBlocked) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", false
+		}
+		return err.Error(), true
+	}
+	if retryStatus[resp.StatusCode] {
+		return resp.Status, true
+	}
+	return "", false
+}
+
+// backoff 指数退避并加入抖动：base * 2^attempt 的 [1/2, 1] 倍
+func backoff(attempt int) time.Duration {
+	d := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter 解析 Retry-After，支持秒数与 HTTP 日期
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// sleep 可被 ctx 取消的等待
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
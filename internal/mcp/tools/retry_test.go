@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterCancelledWait(t *testing.T) {
+	l := &hostLimiter{last: make(map[string]time.Time)}
+	interval := 200 * time.Millisecond
+	start := time.Now()
+	if err := l.wait(context.Background(), "example.com", interval); err != nil {
+		t.Fatal(err)
+	}
+
+	// 等待中被取消的调用不能占用下一个请求的时间
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := l.wait(ctx, "example.com", interval)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("wait err = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+
+	if err := l.wait(context.Background(), "example.com", interval); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < interval || elapsed > 2*interval {
+		t.Errorf("next request after %s, want about %s", elapsed, interval)
+	}
+}
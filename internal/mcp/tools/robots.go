@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/temoto/robotstxt"
+)
+
+// loadRobots 获取并解析站点的 robots.txt，4xx 视为全部允许
+func loadRobots(u *url.URL, opts *ScraperOptions) (*robotstxt.RobotsData, error) {
+	resp, err := httpDo(u.Scheme+"://"+u.Host+"/robots.txt", opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	robots, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parse robots.txt failed: %w", err)
+	}
+	return robots, nil
+}
+
+// checkRobots 检查 agent 是否允许访问 URL，并返回该站点的 crawl-delay
+func checkRobots(rawURL, agent string, opts *ScraperOptions) (time.Duration, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	robots, err := loadRobots(u, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	group := robots.FindGroup(agent)
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !group.Test(path) {
+		return 0, fmt.Errorf("%w: %s", colly.ErrRobotsTxtBlocked, rawURL)
+	}
+	return group.CrawlDelay, nil
+}
@@ -1,67 +1,202 @@
 package tools
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"mcp-server/internal/outbound"
+
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 )
 
 // ScrapeResult 抓取结果
 type ScrapeResult struct {
-	URL      string `json:"url"`
-	Title    string `json:"title"`
-	Markdown string `json:"markdown"`
+	URL      string    `json:"url"`
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Markdown string    `json:"markdown"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	Cache    string    `json:"cache,omitempty"`
+	Retries  []string  `json:"retries,omitempty"`
 }
 
-// Scraper 网页抓取器
+// Scraper 网页抓取器。通过 WithOptions / RespectRobots 配置完成后可在多个 goroutine 间共享，
+// 每次请求新建采集器与 Cookie Jar，回调、结果与 Cookie 互不影响，只共用连接池
 type Scraper struct {
-	collector *colly.Collector
+	opts          *ScraperOptions
+	respectRobots bool
+}
+
+// sharedScraper 便捷函数共用的抓取器，首次使用时按当时的默认配置创建
+var sharedScraper = sync.OnceValue(NewScraper)
+
+// ctxPolicyError 请求上下文中记录策略拒绝原因的键
+const ctxPolicyError = "policy_error"
+
+// policyError 被抓取策略拒绝的错误，不再回退到纯 HTTP
+type policyError struct {
+	error
 }
 
 // NewScraper 创建新的抓取器
 func NewScraper() *Scraper {
+	return (&Scraper{}).WithOptions(DefaultScraperOptions())
+}
+
+// WithOptions 设置抓取器选项，需在共享抓取器之前调用
+func (s *Scraper) WithOptions(opts *ScraperOptions) *Scraper {
+	s.opts = opts
+	return s
+}
+
+// RespectRobots 设置是否遵守 robots.txt 的 Disallow 与 Crawl-delay，需在共享抓取器之前调用
+func (s *Scraper) RespectRobots(v bool) *Scraper {
+	s.respectRobots = v
+	return s
+}
+
+// prepare 开始一次请求：按需检查 robots.txt，返回截止时间、本次请求的选项与采集器。
+// 本次请求的选项带有独立的 Cookie Jar 与 robots.txt 的 Crawl-delay，回退请求也应使用它
+func (s *Scraper) prepare(url string) (time.Time, *ScraperOptions, *colly.Collector, error) {
+	deadline := time.Now().Add(s.opts.Timeout)
 	c := colly.NewCollector(
 		colly.AllowURLRevisit(),
 		colly.MaxDepth(1),
 	)
-	return &Scraper{collector: c}
+	c.IgnoreRobotsTxt = !s.respectRobots
+
+	opts := *s.opts
+	if opts.Jar == nil {
+		opts.Jar = newCookieJar()
+	}
+	if s.respectRobots {
+		delay, err := checkRobots(url, c.UserAgent, s.opts)
+		if err != nil {
+			return deadline, nil, nil, err
+		}
+		// Crawl-delay 只作用于本次请求，并受服务端上限约束
+		opts.HostInterval = max(opts.HostInterval, min(delay, HostIntervalLimit))
+	}
+
+	opts.apply(c)
+	fixCharset(c)
+	// 根据响应头提前拒绝超限或类型不符的响应
+	c.OnResponseHeaders(func(r *colly.Response) {
+		if err := opts.checkHeaders(*r.Headers); err != nil {
+			r.Ctx.Put(ctxPolicyError, err)
+			r.Request.Abort()
+		}
+	})
+	return deadline, &opts, c, nil
 }
 
 // FetchToMarkdown 抓取网页并转换为 Markdown
 func (s *Scraper) FetchToMarkdown(url string) (*ScrapeResult, error) {
+	deadline, opts, c, err := s.prepare(url)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &ScrapeResult{URL: url}
 
 	var bodyContent strings.Builder
 	var title string
+	var meta *Metadata
+	var cache, contentType string
+	var retries []string
+	var body []byte
+
+	c.OnResponse(func(r *colly.Response) {
+		cache = r.Headers.Get(cacheHeader)
+		retries = r.Headers.Values(retryHeader)
+		contentType = r.Headers.Get("Content-Type")
+		body = r.Body
+	})
+	c.OnError(func(r *colly.Response, _ error) {
+		if r.Headers != nil {
+			retries = r.Headers.Values(retryHeader)
+		}
+	})
 
-	s.collector.OnHTML("title", func(e *colly.HTMLElement) {
+	c.OnHTML("title", func(e *colly.HTMLElement) {
 		title = strings.TrimSpace(e.Text)
 	})
 
-	s.collector.OnHTML("body", func(e *colly.HTMLElement) {
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		meta = extractMetadata(e.DOM, url)
+	})
+
+	c.OnHTML("body", func(e *colly.HTMLElement) {
 		// 提取主要内容区域
 		content := extractContent(e)
 		bodyContent.WriteString(content)
 	})
 
-	err := s.collector.Visit(url)
+	err = s.visit(c, url)
+	if isFinal(err) {
+		return nil, err
+	}
+	// PDF、纯文本、JSON 等文档交给对应的提取器，colly 已按声明的编码转码
+	if docType := detectDocType(url, contentType, body); err == nil && docType != DocHTML {
+		doc, err := extractDocument(url, docType, "", body, opts)
+		if err != nil {
+			return nil, err
+		}
+		doc.Cache, doc.Retries = cache, retries
+		return doc, nil
+	}
 	if err != nil || bodyContent.Len() == 0 {
 		// 回退到纯 HTTP + goquery（带 UA），适配反爬/JS 渲染站点
-		return httpFallbackFetch(url)
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("fetch deadline of %s exceeded", s.opts.Timeout)
+		}
+		fallback, err := httpFallbackFetch(url, opts.withTimeout(remaining))
+		if err != nil {
+			return nil, err
+		}
+		fallback.Retries = append(retries, fallback.Retries...)
+		return fallback, nil
 	}
 
+	result.Type = DocHTML
 	result.Title = title
 	result.Markdown = formatMarkdown(title, bodyContent.String())
+	result.Metadata = meta
+	result.Cache = cache
+	result.Retries = retries
 
 	return result, nil
 }
 
+// visit 使用单次请求的采集器抓取 URL，响应头被策略拒绝时返回 policyError
+func (s *Scraper) visit(c *colly.Collector, url string) error {
+	if err := outbound.CheckURL(url); err != nil {
+		return err
+	}
+
+	ctx := colly.NewContext()
+	err := c.Request(http.MethodGet, url, nil, ctx, nil)
+	if perr, ok := ctx.GetAny(ctxPolicyError).(error); ok {
+		return policyError{perr}
+	}
+	return err
+}
+
+// isFinal 判断错误是否不应再回退重试
+func isFinal(err error) bool {
+	var perr policyError
+	return errors.Is(err, colly.ErrRobotsTxtBlocked) || errors.Is(err, outbound.ErrBlocked) || errors.As(err, &perr)
+}
+
 // extractContent 从 HTML 元素提取内容并转换为 Markdown
 func extractContent(e *colly.HTMLElement) string {
 	var md strings.Builder
@@ -133,44 +268,143 @@ func formatMarkdown(title, content string) string {
 	return md.String()
 }
 
-// QuickFetch 快速抓取（便捷函数）
+// QuickFetch 快速抓取（便捷函数），使用共享的抓取器
 func QuickFetch(url string) (*ScrapeResult, error) {
-	return NewScraper().FetchToMarkdown(url)
+	return sharedScraper().FetchToMarkdown(url)
 }
 
-// httpFallbackFetch 使用原生 HTTP + goquery 回退抓取
-func httpFallbackFetch(url string) (*ScrapeResult, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+// httpDo 发起带浏览器 UA 的 GET 请求
+func httpDo(url string, opts *ScraperOptions) (*http.Response, error) {
+	if err := outbound.CheckURL(url); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport:     opts.transport(),
+		Jar:           opts.Jar,
+		Timeout:       opts.Timeout,
+		CheckRedirect: opts.redirectPolicy(),
+	}
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("build request failed: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
 
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http fetch failed: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
+
+// httpGet 发起 GET 请求，非 2xx 状态或响应头不符合选项时视为错误
+func httpGet(url string, opts *ScraperOptions) (*http.Response, error) {
+	resp, err := httpDo(url, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		if retries := resp.Header.Values(retryHeader); len(retries) > 0 {
+			return nil, fmt.Errorf("unexpected status: %d (%s)", resp.StatusCode, strings.Join(retries, "; "))
+		}
 		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
 	}
+	if err := opts.checkHeaders(resp.Header); err != nil {
+		resp.Body.Close()
+		return nil, policyError{err}
+	}
+	return resp, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// readBody 读取响应体，超出 MaxBodySize 的部分截断
+func readBody(resp *http.Response, opts *ScraperOptions) ([]byte, error) {
+	var r io.Reader = resp.Body
+	if opts.MaxBodySize > 0 {
+		r = io.LimitReader(r, int64(opts.MaxBodySize))
+	}
+	body, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("read body failed: %w", err)
 	}
+	return body, nil
+}
+
+// httpGetBody 发起 GET 请求并读取响应体
+func httpGetBody(url string, opts *ScraperOptions) ([]byte, error) {
+	resp, err := httpGet(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return readBody(resp, opts)
+}
+
+// httpGetHTML 发起 GET 请求并将 HTML 响应体转码为 UTF-8，同时返回响应头
+func httpGetHTML(url string, opts *ScraperOptions) ([]byte, http.Header, error) {
+	resp, err := httpGet(url, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	body, err := readBody(resp, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return toUTF8(body, resp.Header.Get("Content-Type")), resp.Header, nil
+}
+
+// httpFallbackFetch 使用原生 HTTP + goquery 回退抓取，非 HTML 文档交给对应的提取器
+func httpFallbackFetch(url string, opts *ScraperOptions) (*ScrapeResult, error) {
+	resp, err := httpGet(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp, opts)
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header
+	contentType := header.Get("Content-Type")
+
+	if docType := detectDocType(url, contentType, body); docType != DocHTML {
+		result, err := extractDocument(url, docType, contentType, body, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Cache, result.Retries = header.Get(cacheHeader), header.Values(retryHeader)
+		return result, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(toUTF8(body, contentType)))
 	if err != nil {
 		return nil, fmt.Errorf("parse html failed: %w", err)
 	}
 
 	title := strings.TrimSpace(doc.Find("title").First().Text())
+
+	return &ScrapeResult{
+		URL:      url,
+		Type:     DocHTML,
+		Title:    title,
+		Markdown: formatMarkdown(title, selectionToMarkdown(doc.Selection)),
+		Metadata: extractMetadata(doc.Selection, url),
+		Cache:    header.Get(cacheHeader),
+		Retries:  header.Values(retryHeader),
+	}, nil
+}
+
+// selectionToMarkdown 将 goquery 选区转换为 Markdown
+func selectionToMarkdown(doc *goquery.Selection) string {
 	var md strings.Builder
 
 	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, sel *goquery.Selection) {
@@ -214,9 +448,5 @@ func httpFallbackFetch(url string) (*ScrapeResult, error) {
 		}
 	})
 
-	return &ScrapeResult{
-		URL:      url,
-		Title:    title,
-		Markdown: formatMarkdown(title, md.String()),
-	}, nil
+	return md.String()
 }
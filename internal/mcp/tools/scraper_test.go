@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"mcp-server/internal/outbound"
+)
+
+func TestScraperCookiesNotShared(t *testing.T) {
+	policy := outbound.Default()
+	allowPrivate := policy.AllowPrivate
+	policy.AllowPrivate = true
+	defer func() { policy.AllowPrivate = allowPrivate }()
+
+	var mu sync.Mutex
+	var cookies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		mu.Unlock()
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret", Path: "/"})
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>t</title></head><body><p>hello</p></body></html>"))
+	}))
+	defer srv.Close()
+
+	opts := DefaultScraperOptions()
+	opts.Cache = nil
+	s := NewScraper().WithOptions(opts)
+	for _, path := range []string{"/login", "/next"} {
+		if _, err := s.FetchToMarkdown(srv.URL + path); err != nil {
+			t.Fatalf("fetch %s: %v", path, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cookies) != 2 {
+		t.Fatalf("got %d requests, want 2", len(cookies))
+	}
+	if cookies[1] != "" {
+		t.Errorf("second fetch sent Cookie %q, want none", cookies[1])
+	}
+}
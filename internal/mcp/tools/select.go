@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/gocolly/colly/v2"
+	"golang.org/x/net/html"
+)
+
+// SelectOptions 选择器抽取选项
+type SelectOptions struct {
+	CSS   []string // CSS 选择器
+	XPath []string // XPath 表达式
+	Attr  string   // 提取的属性名（为空时提取文本）
+	HTML  bool     // 提取内部 HTML 而非文本
+}
+
+// SelectMatch 单个选择器的匹配结果
+type SelectMatch struct {
+	Selector string   `json:"selector"`
+	Kind     string   `json:"kind"`
+	Values   []string `json:"values"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// SelectResult 选择器抽取结果
+type SelectResult struct {
+	URL     string        `json:"url"`
+	Cache   string        `json:"cache,omitempty"`
+	Retries []string      `json:"retries,omitempty"`
+	Matches []SelectMatch `json:"matches"`
+}
+
+// Select 抓取网页并按 CSS / XPath 选择器抽取内容
+func (s *Scraper) Select(url string, opts *SelectOptions) (*SelectResult, error) {
+	if len(opts.CSS) == 0 && len(opts.XPath) == 0 {
+		return nil, fmt.Errorf("at least one selector is required")
+	}
+
+	deadline, fetchOpts, c, err := s.prepare(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	var cache string
+	var retries []string
+	c.OnResponse(func(r *colly.Response) {
+		body = r.Body
+		cache = r.Headers.Get(cacheHeader)
+		retries = r.Headers.Values(retryHeader)
+	})
+	c.OnError(func(r *colly.Response, _ error) {
+		if r.Headers != nil {
+			retries = r.Headers.Values(retryHeader)
+		}
+	})
+
+	err = s.visit(c, url)
+	if isFinal(err) {
+		return nil, err
+	}
+	if err != nil || len(body) == 0 {
+		// 与 FetchToMarkdown 相同，回退到纯 HTTP
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("fetch deadline of %s exceeded", s.opts.Timeout)
+		}
+		var header http.Header
+		body, header, err = httpGetHTML(url, fetchOpts.withTimeout(remaining))
+		if err != nil {
+			return nil, err
+		}
+		cache = header.Get(cacheHeader)
+		retries = append(retries, header.Values(retryHeader)...)
+	}
+
+	result, err := selectFromHTML(url, body, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Cache = cache
+	result.Retries = retries
+	return result, nil
+}
+
+// selectFromHTML 在 HTML 文本上执行选择器
+func selectFromHTML(url string, body []byte, opts *SelectOptions) (*SelectResult, error) {
+	result := &SelectResult{URL: url, Matches: []SelectMatch{}}
+
+	if len(opts.CSS) > 0 {
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("parse html failed: %w", err)
+		}
+		for _, sel := range opts.CSS {
+			result.Matches = append(result.Matches, selectCSS(doc, sel, opts))
+		}
+	}
+
+	if len(opts.XPath) > 0 {
+		root, err := htmlquery.Parse(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("parse html failed: %w", err)
+		}
+		for _, expr := range opts.XPath {
+			result.Matches = append(result.Matches, selectXPath(root, expr, opts))
+		}
+	}
+
+	return result, nil
+}
+
+// selectCSS 执行单个 CSS 选择器
+func selectCSS(doc *goquery.Document, selector string, opts *SelectOptions) SelectMatch {
+	m := SelectMatch{Selector: selector, Kind: "css", Values: []string{}}
+
+	// 预先编译，非法选择器直接报错而不是静默返回空
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		m.Error = err.Error()
+		return m
+	}
+
+	doc.FindMatcher(matcher).Each(func(_ int, sel *goquery.Selection) {
+		var v string
+		switch {
+		case opts.Attr != "":
+			attr, ok := sel.Attr(opts.Attr)
+			if !ok {
+				return
+			}
+			v = strings.TrimSpace(attr)
+		case opts.HTML:
+			v, _ = sel.Html()
+			v = strings.TrimSpace(v)
+		default:
+			v = cleanText(sel.Text())
+		}
+		if v != "" {
+			m.Values = append(m.Values, v)
+		}
+	})
+	return m
+}
+
+// selectXPath 执行单个 XPath 表达式
+func selectXPath(root *html.Node, expr string, opts *SelectOptions) SelectMatch {
+	m := SelectMatch{Selector: expr, Kind: "xpath", Values: []string{}}
+
+	nodes, err := htmlquery.QueryAll(root, expr)
+	if err != nil {
+		m.Error = err.Error()
+		return m
+	}
+
+	for _, n := range nodes {
+		var v string
+		switch {
+		case opts.Attr != "":
+			if !htmlquery.ExistsAttr(n, opts.Attr) {
+				continue
+			}
+			v = strings.TrimSpace(htmlquery.SelectAttr(n, opts.Attr))
+		case opts.HTML:
+			v = strings.TrimSpace(htmlquery.OutputHTML(n, false))
+		default:
+			v = cleanText(htmlquery.InnerText(n))
+		}
+		if v != "" {
+			m.Values = append(m.Values, v)
+		}
+	}
+	return m
+}
+
+// QuickSelect 快速选择器抽取（便捷函数）
+func QuickSelect(url string, opts *SelectOptions) (*SelectResult, error) {
+	return sharedScraper().Select(url, opts)
+}
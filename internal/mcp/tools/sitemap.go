@@ -0,0 +1,257 @@
+package tools
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// sitemapMaxFiles 最多读取的站点地图文件数（含索引）
+const sitemapMaxFiles = 20
+
+// errSitemapTooLarge 站点地图超过字节上限，之前解析出的 URL 仍然保留
+var errSitemapTooLarge = errors.New("sitemap exceeds size limit")
+
+// SitemapURL 站点地图中的单个 URL
+type SitemapURL struct {
+	Loc        string  `json:"loc"`
+	LastMod    string  `json:"lastmod,omitempty"`
+	ChangeFreq string  `json:"changefreq,omitempty"`
+	Priority   float64 `json:"priority,omitempty"`
+}
+
+// SitemapResult 站点地图发现结果
+type SitemapResult struct {
+	Site      string       `json:"site"`
+	Sitemaps  []string     `json:"sitemaps"`
+	Count     int          `json:"count"`
+	Truncated bool         `json:"truncated"`
+	URLs      []SitemapURL `json:"urls"`
+	Errors    []string     `json:"errors,omitempty"`
+}
+
+// SitemapLimit 工具调用返回 URL 数的服务端上限
+const SitemapLimit = 5000
+
+// SitemapOptions 站点地图选项
+type SitemapOptions struct {
+	Prefix   string        // 只保留路径以此开头的 URL
+	Limit    int           // 最多返回的 URL 数
+	Timeout  time.Duration // robots.txt 与全部站点地图文件共享的总时限，为 0 时使用默认抓取时限
+	MaxBytes int           // 单个站点地图下载与解压后各自的最大字节数，为 0 时使用默认响应体上限
+}
+
+// Clamp 按服务端上限裁剪选项
+func (o *SitemapOptions) Clamp() *SitemapOptions {
+	o.Limit = min(max(o.Limit, 1), SitemapLimit)
+	o.Timeout = min(max(o.Timeout, 0), TimeoutLimit)
+	o.MaxBytes = min(max(o.MaxBytes, 0), MaxBodySizeLimit)
+	return o
+}
+
+// sitemapItem urlset 中的 url 元素
+type sitemapItem struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// DiscoverSitemap 通过 robots.txt 或默认位置发现并读取站点地图
+func DiscoverSitemap(siteURL string, opts *SitemapOptions) (*SitemapResult, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", siteURL)
+	}
+
+	limits := DefaultScraperOptions()
+	if opts.Timeout > 0 {
+		limits.Timeout = opts.Timeout
+	}
+	if opts.MaxBytes > 0 {
+		limits.MaxBodySize = opts.MaxBytes
+	}
+	deadline := time.Now().Add(limits.Timeout)
+
+	result := &SitemapResult{
+		Site:     u.Scheme + "://" + u.Host,
+		Sitemaps: []string{},
+		URLs:     []SitemapURL{},
+	}
+
+	// 直接给出站点地图地址时跳过发现
+	var queue []string
+	lower := strings.ToLower(u.Path)
+	if strings.HasSuffix(lower, ".xml") || strings.HasSuffix(lower, ".xml.gz") {
+		queue = []string{u.String()}
+	} else {
+		if robots, err := loadRobots(u, limits); err == nil {
+			queue = append(queue, robots.Sitemaps...)
+		}
+		if len(queue) == 0 {
+			queue = []string{result.Site + "/sitemap.xml"}
+		}
+	}
+
+	seen := map[string]bool{}
+	for len(queue) > 0 && len(seen) < sitemapMaxFiles && !result.Truncated {
+		loc := queue[0]
+		queue = queue[1:]
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			result.Truncated = true
+			result.Errors = append(result.Errors, fmt.Sprintf("sitemap deadline of %s exceeded", limits.Timeout))
+			break
+		}
+		children, err := fetchSitemap(loc, limits.withTimeout(remaining), func(item sitemapItem) bool {
+			loc := strings.TrimSpace(item.Loc)
+			if loc == "" || !sitemapMatch(loc, opts.Prefix) {
+				return true
+			}
+			if opts.Limit > 0 && len(result.URLs) >= opts.Limit {
+				result.Truncated = true
+				return false
+			}
+			entry := SitemapURL{
+				Loc:        loc,
+				LastMod:    strings.TrimSpace(item.LastMod),
+				ChangeFreq: strings.TrimSpace(item.ChangeFreq),
+			}
+			if p, err := strconv.ParseFloat(strings.TrimSpace(item.Priority), 64); err == nil {
+				entry.Priority = p
+			}
+			result.URLs = append(result.URLs, entry)
+			return true
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", loc, err))
+			if !errors.Is(err, errSitemapTooLarge) {
+				continue
+			}
+			result.Truncated = true
+		}
+		result.Sitemaps = append(result.Sitemaps, loc)
+		queue = append(queue, children...)
+	}
+	if len(queue) > 0 {
+		result.Truncated = true
+	}
+
+	if len(result.Sitemaps) == 0 {
+		return nil, fmt.Errorf("no sitemap found: %s", strings.Join(result.Errors, "; "))
+	}
+
+	result.Count = len(result.URLs)
+	return result, nil
+}
+
+// fetchSitemap 流式下载并解析单个站点地图，自动识别 gzip。每个 url 元素交给 visit，
+// visit 返回 false 时停止读取；返回索引中列出的子站点地图
+func fetchSitemap(loc string, opts *ScraperOptions, visit func(sitemapItem) bool) ([]string, error) {
+	resp, err := httpGet(loc, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 下载与解压后的数据都受 MaxBodySize 约束
+	limit := int64(opts.MaxBodySize)
+	if limit <= 0 {
+		limit = MaxBodySizeLimit
+	}
+	br := bufio.NewReader(&capReader{r: resp.Body, n: limit})
+	var r io.Reader = br
+	// .xml.gz 以原始 gzip 返回，不会被 http.Client 自动解压
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("gunzip failed: %w", err)
+		}
+		defer zr.Close()
+		r = &capReader{r: zr, n: limit}
+	}
+
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+
+	var children []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return children, nil
+		}
+		if err != nil {
+			return children, fmt.Errorf("parse sitemap failed: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "url":
+			var item sitemapItem
+			if err := dec.DecodeElement(&item, &start); err != nil {
+				return children, fmt.Errorf("parse sitemap failed: %w", err)
+			}
+			if !visit(item) {
+				return children, nil
+			}
+		case "sitemap":
+			var sm struct {
+				Loc string `xml:"loc"`
+			}
+			if err := dec.DecodeElement(&sm, &start); err != nil {
+				return children, fmt.Errorf("parse sitemap failed: %w", err)
+			}
+			if loc := strings.TrimSpace(sm.Loc); loc != "" && len(children) < sitemapMaxFiles {
+				children = append(children, loc)
+			}
+		}
+	}
+}
+
+// capReader 最多读取 n 字节，超出时返回 errSitemapTooLarge 而不是静默截断
+type capReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read 实现 io.Reader
+func (c *capReader) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		return 0, errSitemapTooLarge
+	}
+	if int64(len(p)) > c.n {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	return n, err
+}
+
+// sitemapMatch 判断 URL 路径是否匹配前缀
+func sitemapMatch(loc, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(u.Path, prefix)
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-server/internal/outbound"
+)
+
+func TestDiscoverSitemapLimits(t *testing.T) {
+	policy := outbound.Default()
+	allowPrivate := policy.AllowPrivate
+	policy.AllowPrivate = true
+	defer func() { policy.AllowPrivate = allowPrivate }()
+
+	// 压缩后很小、解压后约 1MB 的站点地图
+	var xml bytes.Buffer
+	xml.WriteString(`<?xml version="1.0"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	total := 0
+	for ; xml.Len() < 1<<20; total++ {
+		fmt.Fprintf(&xml, "<url><loc>https://example.com/page/%d</loc></url>", total)
+	}
+	xml.WriteString("</urlset>")
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(xml.Bytes())
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gz.Bytes())
+	}))
+	defer srv.Close()
+
+	cases := map[string]struct {
+		opts SitemapOptions
+		want int // 期望的 URL 数，0 表示只读取了一部分
+	}{
+		"url limit":  {opts: SitemapOptions{Limit: 10, MaxBytes: 2 << 20}, want: 10},
+		"size limit": {opts: SitemapOptions{Limit: SitemapLimit, MaxBytes: 64 << 10}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := DiscoverSitemap(srv.URL+"/sitemap.xml.gz", &tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Truncated {
+				t.Error("truncated = false, want true")
+			}
+			if tc.want > 0 && result.Count != tc.want {
+				t.Errorf("count = %d, want %d", result.Count, tc.want)
+			}
+			if tc.want == 0 && (result.Count == 0 || result.Count >= total) {
+				t.Errorf("count = %d, want between 0 and %d", result.Count, total)
+			}
+		})
+	}
+}
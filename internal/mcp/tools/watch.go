@@ -0,0 +1,378 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// 监控相关的服务端上限
+const (
+	WatchCheckLimit    = 10        // 单次 watch_check 最多检查的页面数
+	WatchLimit         = 200       // 最多保存的监控项数
+	watchSnapshotLimit = 256 << 10 // 快照的最大字节数，超过部分不参与比较
+	watchDiffLimit     = 20000     // diff 输出的最大字符数
+)
+
+var (
+	// ErrWatchNotFound 未找到监控项
+	ErrWatchNotFound = errors.New("watch not found")
+	// ErrTooManyWatches 监控项数量已达上限
+	ErrTooManyWatches = fmt.Errorf("too many watches (max %d)", WatchLimit)
+)
+
+// Watch 页面监控项，保存规范化后的 Markdown 快照与哈希
+type Watch struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Hash      string    `json:"hash"`
+	Markdown  string    `json:"markdown,omitempty"`
+	AddedAt   time.Time `json:"added_at"`
+	CheckedAt time.Time `json:"checked_at"`
+	ChangedAt time.Time `json:"changed_at,omitzero"`
+}
+
+// WatchCheck 一次检查的结果
+type WatchCheck struct {
+	URL     string `json:"url"`
+	Title   string `json:"title,omitempty"`
+	Changed bool   `json:"changed"`
+	OldHash string `json:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty"`
+	Diff    string `json:"diff,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// WatchStore 监控项存储
+type WatchStore interface {
+	Get(url string) (*Watch, error)
+	Put(w *Watch) error // 新增监控项超过 WatchLimit 时返回 ErrTooManyWatches
+	List() ([]*Watch, error)
+}
+
+// defaultWatchStore 进程级默认存储，Vercel 上只使用内存，冷启动后丢失
+var defaultWatchStore WatchStore = NewMemoryWatchStore()
+
+// SetDefaultWatchStore 设置默认监控存储
+func SetDefaultWatchStore(s WatchStore) {
+	defaultWatchStore = s
+}
+
+// ==================== 内存存储 ====================
+
+// MemoryWatchStore 内存监控存储
+type MemoryWatchStore struct {
+	mu      sync.Mutex
+	watches map[string]*Watch
+}
+
+// NewMemoryWatchStore 创建内存监控存储
+func NewMemoryWatchStore() *MemoryWatchStore {
+	return &MemoryWatchStore{watches: make(map[string]*Watch)}
+}
+
+// Get 读取监控项
+func (m *MemoryWatchStore) Get(url string) (*Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.watches[url]
+	if !ok {
+		return nil, ErrWatchNotFound
+	}
+	cp := *w
+	return &cp, nil
+}
+
+// Put 写入监控项
+func (m *MemoryWatchStore) Put(w *Watch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.watches[w.URL]; !ok && len(m.watches) >= WatchLimit {
+		return ErrTooManyWatches
+	}
+	cp := *w
+	m.watches[w.URL] = &cp
+	return nil
+}
+
+// List 列出全部监控项
+func (m *MemoryWatchStore) List() ([]*Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]*Watch, 0, len(m.watches))
+	for _, w := range m.watches {
+		cp := *w
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+// ==================== 文件存储 ====================
+
+// FileWatchStore 以单个 JSON 文件保存的监控存储，适用于常驻的 cmd/server
+type FileWatchStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileWatchStore 创建文件监控存储
+func NewFileWatchStore(path string) (*FileWatchStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileWatchStore{path: path}, nil
+}
+
+// load 读取全部监控项，文件不存在时返回空表
+func (f *FileWatchStore) load() (map[string]*Watch, error) {
+	watches := make(map[string]*Watch)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return watches, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read watch store failed: %w", err)
+	}
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return nil, fmt.Errorf("parse watch store failed: %w", err)
+	}
+	return watches, nil
+}
+
+// Get 读取监控项
+func (f *FileWatchStore) Get(url string) (*Watch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	watches, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	w, ok := watches[url]
+	if !ok {
+		return nil, ErrWatchNotFound
+	}
+	return w, nil
+}
+
+// Put 写入监控项，先写临时文件再重命名
+func (f *FileWatchStore) Put(w *Watch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	watches, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := watches[w.URL]; !ok && len(watches) >= WatchLimit {
+		return ErrTooManyWatches
+	}
+	watches[w.URL] = w
+
+	data, err := json.MarshalIndent(watches, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".watches-*")
+	if err != nil {
+		return fmt.Errorf("write watch store failed: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write watch store failed: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write watch store failed: %w", err)
+	}
+	return nil
+}
+
+// List 列出全部监控项
+func (f *FileWatchStore) List() ([]*Watch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	watches, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Watch, 0, len(watches))
+	for _, w := range watches {
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+// ==================== 监控操作 ====================
+
+// watchLocks 按 URL 分片的锁，保证同一页面的读取、比较与写回不会交错
+var watchLocks [64]sync.Mutex
+
+// lockWatch 锁定 URL 所在的分片，返回解锁函数
+func lockWatch(url string) func() {
+	sum := sha256.Sum256([]byte(url))
+	mu := &watchLocks[int(sum[0])%len(watchLocks)]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// AddWatch 抓取页面并保存初始快照，已存在时覆盖
+func AddWatch(rawURL string) (*Watch, error) {
+	defer lockWatch(normalizeURL(rawURL))()
+
+	title, md, err := watchFetch(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	w := &Watch{
+		URL:       normalizeURL(rawURL),
+		Title:     title,
+		Hash:      hashMarkdown(md),
+		Markdown:  md,
+		AddedAt:   now,
+		CheckedAt: now,
+	}
+	if err := defaultWatchStore.Put(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ListWatches 列出监控项，按 URL 排序，不含快照正文
+func ListWatches() ([]*Watch, error) {
+	watches, err := defaultWatchStore.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].URL < watches[j].URL })
+	for _, w := range watches {
+		w.Markdown = ""
+	}
+	return watches, nil
+}
+
+// CheckWatch 重新抓取页面，与上次快照比较并更新快照
+func CheckWatch(rawURL string) (*WatchCheck, error) {
+	url := normalizeURL(rawURL)
+	defer lockWatch(url)()
+
+	w, err := defaultWatchStore.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WatchCheck{URL: w.URL, OldHash: w.Hash}
+	title, md, err := watchFetch(w.URL)
+	// 抓取失败同样记录检查时间，避免批量检查时总是卡在同一批页面
+	w.CheckedAt = time.Now().UTC()
+	if err != nil {
+		result.Error = err.Error()
+		return result, defaultWatchStore.Put(w)
+	}
+
+	result.Title = title
+	result.NewHash = hashMarkdown(md)
+	if result.NewHash != w.Hash {
+		result.Changed = true
+		result.Diff = unifiedDiff(w.Markdown, md)
+		w.Title, w.Hash, w.Markdown, w.ChangedAt = title, result.NewHash, md, w.CheckedAt
+	}
+	if err := defaultWatchStore.Put(w); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckAllWatches 检查最久未检查的若干监控项
+func CheckAllWatches(limit int) ([]*WatchCheck, error) {
+	watches, err := defaultWatchStore.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].CheckedAt.Before(watches[j].CheckedAt) })
+	if limit > 0 && len(watches) > limit {
+		watches = watches[:limit]
+	}
+
+	results := make([]*WatchCheck, 0, len(watches))
+	for _, w := range watches {
+		r, err := CheckWatch(w.URL)
+		if err != nil {
+			r = &WatchCheck{URL: w.URL, Error: err.Error()}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// watchFetch 绕过响应缓存抓取页面并规范化 Markdown
+func watchFetch(rawURL string) (string, string, error) {
+	opts := DefaultScraperOptions()
+	opts.Cache = nil
+	result, err := NewScraper().WithOptions(opts).FetchToMarkdown(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	md := normalizeMarkdown(result.Markdown)
+	if len(md) > watchSnapshotLimit {
+		md = strings.ToValidUTF8(md[:watchSnapshotLimit], "") + "\n"
+	}
+	return result.Title, md, nil
+}
+
+// blankLinesRe 匹配连续的空行
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// normalizeMarkdown 统一换行、去掉行尾空白并合并连续空行，避免无意义的变化
+func normalizeMarkdown(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	md = blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(md) + "\n"
+}
+
+// hashMarkdown 计算快照哈希
+func hashMarkdown(md string) string {
+	sum := sha256.Sum256([]byte(md))
+	return hex.EncodeToString(sum[:])
+}
+
+// unifiedDiff 生成两次快照之间的统一格式 diff，过长时截断
+func unifiedDiff(before, after string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: "previous",
+		ToFile:   "current",
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	if len(diff) > watchDiffLimit {
+		diff = strings.ToValidUTF8(diff[:watchDiffLimit], "") + "\n... (diff 已截断)\n"
+	}
+	return diff
+}
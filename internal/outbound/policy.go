@@ -0,0 +1,287 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ErrBlocked 出站请求被策略拒绝
+var ErrBlocked = errors.New("outbound request blocked")
+
+// Policy 出站请求策略，抓取、爬取与仓库克隆共用
+type Policy struct {
+	Schemes      []string // 允许的协议
+	AllowHosts   []string // 主机白名单，为空表示不限制，支持 *.example.com
+	DenyHosts    []string // 主机黑名单，支持 *.example.com
+	AllowPrivate bool     // 允许访问内网、回环与链路本地地址
+
+	// Proxy 按目标 URL 选择代理，为 nil 表示直连
+	Proxy func(*url.URL) (*url.URL, error)
+	// ProxyAddrs 受信任的代理地址（host:port），连接代理本身时不做地址检查
+	ProxyAddrs []string
+}
+
+// cgnat 运营商级 NAT 地址段，同样视为内网
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// FromEnv 从环境变量读取策略
+//
+//	OUTBOUND_ALLOW_HOSTS   逗号分隔的主机白名单
+//	OUTBOUND_DENY_HOSTS    逗号分隔的主机黑名单
+//	OUTBOUND_ALLOW_PRIVATE 设为 true 时允许访问内网地址
+//	HTTP_PROXY / HTTPS_PROXY / NO_PROXY 标准代理配置
+func FromEnv() *Policy {
+	p := &Policy{
+		Schemes:      []string{"http", "https"},
+		AllowHosts:   splitList(os.Getenv("OUTBOUND_ALLOW_HOSTS")),
+		DenyHosts:    splitList(os.Getenv("OUTBOUND_DENY_HOSTS")),
+		AllowPrivate: os.Getenv("OUTBOUND_ALLOW_PRIVATE") == "true",
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	for _, raw := range []string{cfg.HTTPProxy, cfg.HTTPSProxy} {
+		if addr := proxyAddr(raw); addr != "" && !slices.Contains(p.ProxyAddrs, addr) {
+			p.ProxyAddrs = append(p.ProxyAddrs, addr)
+		}
+	}
+	if len(p.ProxyAddrs) > 0 {
+		p.Proxy = cfg.ProxyFunc()
+	}
+	return p
+}
+
+// proxyAddr 将代理 URL 转换为 Transport 实际拨号的 host:port
+func proxyAddr(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		// 与 net/http 一致，缺少协议时按 http 解析
+		if u, err = url.Parse("http://" + raw); err != nil {
+			return ""
+		}
+	}
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+var (
+	defaultPolicy    = FromEnv()
+	defaultTransport = defaultPolicy.Transport()
+)
+
+// Default 返回进程级默认策略
+func Default() *Policy {
+	return defaultPolicy
+}
+
+// CheckURL 使用默认策略检查 URL
+func CheckURL(raw string) error {
+	return defaultPolicy.CheckURL(raw)
+}
+
+// CheckHost 使用默认策略检查非 HTTP 连接的目标主机
+func CheckHost(ctx context.Context, host string) error {
+	return defaultPolicy.CheckHost(ctx, host)
+}
+
+// Transport 返回使用默认策略的共享 Transport
+func Transport() *http.Transport {
+	return defaultTransport
+}
+
+// CheckURL 检查 URL 的协议与主机是否允许访问，IP 字面量同时检查地址段
+func (p *Policy) CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid URL %q", ErrBlocked, raw)
+	}
+
+	if !slices.Contains(p.Schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlocked, u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host in %q", ErrBlocked, raw)
+	}
+	if err := p.checkHostName(host); err != nil {
+		return err
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	return nil
+}
+
+// CheckHost 检查 SSH 等不经过 Transport 的连接：主机名单与解析出的全部地址。
+// 拨号由第三方库完成，无法在连接时再次检查，因此只能在此提前解析
+func (p *Policy) CheckHost(ctx context.Context, host string) error {
+	host = strings.ToLower(host)
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlocked)
+	}
+	if err := p.checkHostName(host); err != nil {
+		return err
+	}
+	return p.checkResolved(ctx, host)
+}
+
+// checkHostName 检查主机黑白名单
+func (p *Policy) checkHostName(host string) error {
+	if matchHost(p.DenyHosts, host) {
+		return fmt.Errorf("%w: host %s is denied", ErrBlocked, host)
+	}
+	if len(p.AllowHosts) > 0 && !matchHost(p.AllowHosts, host) {
+		return fmt.Errorf("%w: host %s is not in allow list", ErrBlocked, host)
+	}
+	return nil
+}
+
+// checkAddr 检查目标 IP 是否位于内网、回环或链路本地地址段
+func (p *Policy) checkAddr(addr netip.Addr) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() || cgnat.Contains(addr) {
+		return fmt.Errorf("%w: address %s is not allowed", ErrBlocked, addr)
+	}
+	return nil
+}
+
+// checkResolved 解析主机名并检查全部地址，用于经代理访问、无法在拨号时检查的请求
+func (p *Policy) checkResolved(ctx context.Context, host string) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s failed: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := p.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proxy 选择代理，走代理的请求在此处检查目标地址
+func (p *Policy) proxy(req *http.Request) (*url.URL, error) {
+	u, err := p.Proxy(req.URL)
+	if err != nil || u == nil {
+		return u, err
+	}
+	if err := p.checkResolved(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// dialContext 连接受信任的代理时直接拨号，其余连接检查目标地址
+func (p *Policy) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d := p.Dialer()
+	if slices.Contains(p.ProxyAddrs, strings.ToLower(address)) {
+		d.Control = nil
+	}
+	return d.DialContext(ctx, network, address)
+}
+
+// control 在 DNS 解析之后、建立连接之前检查实际连接的地址，防止 DNS 重绑定
+func (p *Policy) control(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %q", ErrBlocked, address)
+	}
+	return p.checkAddr(ap.Addr())
+}
+
+// Dialer 返回在连接前检查目标地址的 Dialer
+func (p *Policy) Dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+}
+
+// Transport 返回应用该策略的 Transport
+func (p *Policy) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// 代理只使用 Policy 中显式配置的，目标地址改为在选择代理时检查
+	t.Proxy = nil
+	if p.Proxy != nil {
+		t.Proxy = p.proxy
+	}
+	t.DialContext = p.dialContext
+	return t
+}
+
+// CheckRedirect 检查重定向目标，可直接用作 http.Client.CheckRedirect
+func (p *Policy) CheckRedirect(req *http.Request, _ []*http.Request) error {
+	return p.CheckURL(req.URL.String())
+}
+
+// Client 返回应用该策略的 HTTP 客户端
+func (p *Policy) Client() *http.Client {
+	return &http.Client{
+		Transport:     p.Transport(),
+		CheckRedirect: p.CheckRedirect,
+	}
+}
+
+// matchHost 判断主机是否匹配列表，*.example.com 匹配所有子域名
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList 拆分逗号分隔的列表
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}